@@ -0,0 +1,285 @@
+package flatten
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// FlattenPaths takes a structure and returns the path of each key returned by Flatten, split into its segments.
+//
+// Unlike the flattened keys, the segments are unambiguous when map keys contain dots, such as annotation names.
+func FlattenPaths(thing map[string]interface{}) map[string][]string {
+	result := make(map[string][]string)
+
+	for k, raw := range thing {
+		if raw == nil || k == "" {
+			continue
+		}
+
+		flattenPaths(result, k, []string{k}, reflect.ValueOf(raw))
+	}
+
+	return result
+}
+
+func flattenPaths(result map[string][]string, prefix string, path []string, v reflect.Value) {
+	if v.Kind() == reflect.Invalid {
+		return
+	}
+
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			if k.Kind() == reflect.Interface {
+				k = k.Elem()
+			}
+
+			key := fmt.Sprintf("%v", k)
+			flattenPaths(result, fmt.Sprintf("%s.%s", prefix, key), appendPath(path, key), v.MapIndex(k))
+		}
+	case reflect.Slice:
+		result[prefix+".#"] = appendPath(path, "#")
+		for i := 0; i < v.Len(); i++ {
+			index := strconv.Itoa(i)
+			flattenPaths(result, fmt.Sprintf("%s.%s", prefix, index), appendPath(path, index), v.Index(i))
+		}
+	default:
+		result[prefix] = path
+	}
+}
+
+func appendPath(path []string, segment string) []string {
+	return append(append(make([]string, 0, len(path)+1), path...), segment)
+}
+
+// Selector matches the paths of flattened keys, using a JSON path like syntax:
+//
+//	spec.replicas                          - fields separated by dots
+//	spec.containers.*.image                - a wildcard matching any key or list index
+//	spec.containers[name=app].resources    - a list item with the field matching the value
+//	spec.containers[0].image               - a list index
+//	metadata.annotations["example.com/foo"] - a quoted key which may contain dots
+type Selector struct {
+	segments []selectorSegment
+}
+
+type selectorSegment struct {
+	key        string
+	wildcard   bool
+	matchField string
+	matchValue string
+	isMatch    bool
+}
+
+// ParseSelector parses the selector expression
+func ParseSelector(expr string) (*Selector, error) {
+	selector := &Selector{}
+	current := strings.Builder{}
+	pending := false
+
+	endSegment := func() {
+		if !pending {
+			return
+		}
+		if current.String() == "*" {
+			selector.segments = append(selector.segments, selectorSegment{wildcard: true})
+		} else {
+			selector.segments = append(selector.segments, selectorSegment{key: current.String()})
+		}
+		current.Reset()
+		pending = false
+	}
+
+	for i := 0; i < len(expr); i++ {
+		switch c := expr[i]; c {
+		case '.':
+			if !pending && (i == 0 || expr[i-1] != ']') {
+				return nil, fmt.Errorf("invalid selector %q: empty field at position %d", expr, i)
+			}
+			endSegment()
+		case '[':
+			endSegment()
+			end, segment, err := parseBracket(expr, i)
+			if err != nil {
+				return nil, err
+			}
+			selector.segments = append(selector.segments, segment)
+			i = end
+		default:
+			current.WriteByte(c)
+			pending = true
+		}
+	}
+	endSegment()
+
+	if len(selector.segments) == 0 {
+		return nil, fmt.Errorf("invalid selector %q: no fields", expr)
+	}
+
+	return selector, nil
+}
+
+// parseBracket parses the bracketed segment starting at the position, returning the position of the closing bracket
+func parseBracket(expr string, start int) (int, selectorSegment, error) {
+	i := start + 1
+	if i < len(expr) && (expr[i] == '"' || expr[i] == '\'') {
+		key, end, err := parseQuoted(expr, i)
+		if err != nil {
+			return 0, selectorSegment{}, err
+		}
+		if end+1 >= len(expr) || expr[end+1] != ']' {
+			return 0, selectorSegment{}, fmt.Errorf("invalid selector %q: expected ] at position %d", expr, end+1)
+		}
+		return end + 1, selectorSegment{key: key}, nil
+	}
+
+	end := strings.IndexByte(expr[i:], ']')
+	if end < 0 {
+		return 0, selectorSegment{}, fmt.Errorf("invalid selector %q: unterminated [ at position %d", expr, start)
+	}
+	end += i
+	content := expr[i:end]
+
+	if content == "*" {
+		return end, selectorSegment{wildcard: true}, nil
+	}
+
+	if _, err := strconv.Atoi(content); err == nil {
+		return end, selectorSegment{key: content}, nil
+	}
+
+	if field, value, ok := strings.Cut(content, "="); ok && field != "" {
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		return end, selectorSegment{isMatch: true, matchField: strings.TrimSpace(field), matchValue: value}, nil
+	}
+
+	return 0, selectorSegment{}, fmt.Errorf("invalid selector %q: unsupported [%s]", expr, content)
+}
+
+// parseQuoted parses the quoted string starting at the position, returning the position of the closing quote
+func parseQuoted(expr string, start int) (string, int, error) {
+	quote := expr[start]
+	value := strings.Builder{}
+	for i := start + 1; i < len(expr); i++ {
+		switch expr[i] {
+		case '\\':
+			if i+1 < len(expr) {
+				i++
+				value.WriteByte(expr[i])
+			}
+		case quote:
+			return value.String(), i, nil
+		default:
+			value.WriteByte(expr[i])
+		}
+	}
+	return "", 0, fmt.Errorf("invalid selector %q: unterminated quote at position %d", expr, start)
+}
+
+// Matches returns true if the path within the object is selected by, or nested within, the selector
+func (s *Selector) Matches(object map[string]interface{}, path []string) bool {
+	if len(path) < len(s.segments) {
+		return false
+	}
+
+	var current interface{} = object
+	for i, segment := range s.segments {
+		switch {
+		case segment.wildcard:
+		case segment.isMatch:
+			list, ok := current.([]interface{})
+			if !ok {
+				return false
+			}
+			index, err := strconv.Atoi(path[i])
+			if err != nil || index < 0 || index >= len(list) {
+				return false
+			}
+			item, ok := list[index].(map[string]interface{})
+			if !ok || item[segment.matchField] == nil || fmt.Sprintf("%v", item[segment.matchField]) != segment.matchValue {
+				return false
+			}
+		default:
+			if path[i] != segment.key {
+				return false
+			}
+		}
+
+		current = child(current, path[i])
+	}
+
+	return true
+}
+
+func child(current interface{}, key string) interface{} {
+	switch v := current.(type) {
+	case map[string]interface{}:
+		return v[key]
+	case []interface{}:
+		if index, err := strconv.Atoi(key); err == nil && index >= 0 && index < len(v) {
+			return v[index]
+		}
+	}
+	return nil
+}
+
+// Set replaces every value within the object selected by the selector, returning the number of values replaced
+func (s *Selector) Set(object map[string]interface{}, value interface{}) int {
+	return s.set(object, s.segments, value)
+}
+
+func (s *Selector) set(current interface{}, segments []selectorSegment, value interface{}) int {
+	segment := segments[0]
+	last := len(segments) == 1
+	count := 0
+
+	visit := func(child interface{}, replace func(interface{})) {
+		if last {
+			replace(value)
+			count++
+		} else {
+			count += s.set(child, segments[1:], value)
+		}
+	}
+
+	switch v := current.(type) {
+	case map[string]interface{}:
+		if segment.isMatch {
+			return 0
+		}
+		for k, child := range v {
+			if segment.wildcard || k == segment.key {
+				k := k
+				visit(child, func(n interface{}) { v[k] = n })
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			switch {
+			case segment.wildcard:
+			case segment.isMatch:
+				item, ok := child.(map[string]interface{})
+				if !ok || item[segment.matchField] == nil || fmt.Sprintf("%v", item[segment.matchField]) != segment.matchValue {
+					continue
+				}
+			default:
+				if strconv.Itoa(i) != segment.key {
+					continue
+				}
+			}
+			i := i
+			visit(child, func(n interface{}) { v[i] = n })
+		}
+	}
+
+	return count
+}
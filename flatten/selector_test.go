@@ -0,0 +1,142 @@
+package flatten
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFlattenPaths(t *testing.T) {
+	paths := FlattenPaths(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				"example.com/foo": "bar",
+			},
+		},
+		"list": []interface{}{
+			map[string]interface{}{"name": "first"},
+		},
+	})
+
+	assert.Equal(t, map[string][]string{
+		"metadata.annotations.example.com/foo": {"metadata", "annotations", "example.com/foo"},
+		"list.#":                               {"list", "#"},
+		"list.0.name":                          {"list", "0", "name"},
+	}, paths)
+}
+
+func TestParseSelector(t *testing.T) {
+	testCases := []struct {
+		expr     string
+		expected []selectorSegment
+		wantErr  bool
+	}{
+		{
+			expr:     "spec.replicas",
+			expected: []selectorSegment{{key: "spec"}, {key: "replicas"}},
+		},
+		{
+			expr:     "spec.containers.*.image",
+			expected: []selectorSegment{{key: "spec"}, {key: "containers"}, {wildcard: true}, {key: "image"}},
+		},
+		{
+			expr:     "containers[name=app].resources",
+			expected: []selectorSegment{{key: "containers"}, {isMatch: true, matchField: "name", matchValue: "app"}, {key: "resources"}},
+		},
+		{
+			expr:     `containers[name="app.v1"]`,
+			expected: []selectorSegment{{key: "containers"}, {isMatch: true, matchField: "name", matchValue: "app.v1"}},
+		},
+		{
+			expr:     "containers[0].image",
+			expected: []selectorSegment{{key: "containers"}, {key: "0"}, {key: "image"}},
+		},
+		{
+			expr:     `metadata.annotations["example.com/foo"]`,
+			expected: []selectorSegment{{key: "metadata"}, {key: "annotations"}, {key: "example.com/foo"}},
+		},
+		{
+			expr:     `metadata.annotations['example.com/"quoted\']']`,
+			expected: []selectorSegment{{key: "metadata"}, {key: "annotations"}, {key: `example.com/"quoted']`}},
+		},
+		{expr: "", wantErr: true},
+		{expr: "spec..replicas", wantErr: true},
+		{expr: "containers[name=app", wantErr: true},
+		{expr: `annotations["example.com/foo`, wantErr: true},
+		{expr: "containers[app]", wantErr: true},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(tcase.expr, func(t *testing.T) {
+			selector, err := ParseSelector(tcase.expr)
+			if tcase.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tcase.expected, selector.segments)
+		})
+	}
+}
+
+func TestSelectorMatches(t *testing.T) {
+	object := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "app", "image": "app:1"},
+				map[string]interface{}{"name": "sidecar", "image": "sidecar:1"},
+			},
+		},
+	}
+
+	testCases := []struct {
+		expr     string
+		path     []string
+		expected bool
+	}{
+		{expr: "spec.containers", path: []string{"spec", "containers", "0", "image"}, expected: true},
+		{expr: "spec.containers.*.image", path: []string{"spec", "containers", "1", "image"}, expected: true},
+		{expr: "spec.containers.*.image", path: []string{"spec", "containers", "1", "name"}, expected: false},
+		{expr: "spec.containers[name=sidecar].image", path: []string{"spec", "containers", "1", "image"}, expected: true},
+		{expr: "spec.containers[name=sidecar].image", path: []string{"spec", "containers", "0", "image"}, expected: false},
+		{expr: "spec.containers[name=app]", path: []string{"spec", "containers", "#"}, expected: false},
+		{expr: "spec.containers[1]", path: []string{"spec", "containers", "1", "name"}, expected: true},
+		{expr: "spec.containers.0.image.tag", path: []string{"spec", "containers", "0", "image"}, expected: false},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(tcase.expr, func(t *testing.T) {
+			selector, err := ParseSelector(tcase.expr)
+			assert.NoError(t, err)
+			assert.Equal(t, tcase.expected, selector.Matches(object, tcase.path))
+		})
+	}
+}
+
+func TestSelectorSet(t *testing.T) {
+	object := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "app", "image": "app:1"},
+				map[string]interface{}{"name": "sidecar", "image": "sidecar:1"},
+			},
+		},
+	}
+
+	selector, err := ParseSelector("spec.containers[name=sidecar].image")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, selector.Set(object, "hidden"))
+
+	selector, err = ParseSelector("spec.containers.*.missing")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, selector.Set(object, "hidden"))
+
+	assert.Equal(t, map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "app", "image": "app:1"},
+				map[string]interface{}{"name": "sidecar", "image": "hidden"},
+			},
+		},
+	}, object)
+}
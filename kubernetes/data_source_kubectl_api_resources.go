@@ -0,0 +1,210 @@
+package kubernetes
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+func dataSourceKubectlAPIResources() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceKubectlAPIResourcesRead,
+		Schema: map[string]*schema.Schema{
+			"kubeconfig_context": dataSourceKubeconfigContextSchema,
+			"group": {
+				Type:        schema.TypeString,
+				Description: "Only return the API resources of the group. Set to an empty string to only return the core group.",
+				Optional:    true,
+			},
+			"api_versions": {
+				Type:     schema.TypeList,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Computed: true,
+			},
+			"groups": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"preferred_version": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"versions": {
+							Type:     schema.TypeList,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+							Computed: true,
+						},
+					},
+				},
+			},
+			"resources": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"kind": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"group": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"version": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"api_version": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"namespaced": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"verbs": {
+							Type:     schema.TypeList,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+							Computed: true,
+						},
+						"short_names": {
+							Type:     schema.TypeList,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceKubectlAPIResourcesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider, err := getContextProvider(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	discoveryClient, err := provider.ToDiscoveryClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// list the resources currently served by the cluster, rather than those cached by earlier operations
+	if err := provider.RefreshDiscovery(provider.DiscoveryGeneration()); err != nil {
+		return diag.FromErr(err)
+	}
+
+	apiGroups, apiResources, err := discoveryClient.ServerGroupsAndResources()
+
+	// continue with the groups which were discovered, as a single failing aggregated api shouldn't prevent the
+	// other groups from being used
+	var diags diag.Diagnostics
+	if err != nil {
+		if !discovery.IsGroupDiscoveryFailedError(err) {
+			return diag.FromErr(err)
+		}
+
+		log.Printf("[WARN] failed to discover all api groups: %+v", err)
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Failed to discover all API groups",
+			Detail:   err.Error(),
+		})
+	}
+
+	var groupFilter *string
+	if rawGroup := d.GetRawConfig().GetAttr("group"); !rawGroup.IsNull() && rawGroup.IsKnown() {
+		group := rawGroup.AsString()
+		groupFilter = &group
+	}
+
+	groups, resources, apiVersions := flattenAPIResources(apiGroups, apiResources, groupFilter)
+
+	_ = d.Set("groups", groups)
+	_ = d.Set("resources", resources)
+	_ = d.Set("api_versions", apiVersions)
+
+	d.SetId(fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(apiVersions, ",")))))
+	return diags
+}
+
+// flattenAPIResources converts the discovered groups and resources into the data source attributes, optionally
+// filtered by group. Subresources, such as deployments/scale, are excluded.
+func flattenAPIResources(apiGroups []*meta_v1.APIGroup, apiResources []*meta_v1.APIResourceList, groupFilter *string) ([]map[string]interface{}, []map[string]interface{}, []string) {
+	includeGroup := func(group string) bool {
+		return groupFilter == nil || *groupFilter == group
+	}
+
+	groups := make([]map[string]interface{}, 0, len(apiGroups))
+	for _, apiGroup := range apiGroups {
+		if !includeGroup(apiGroup.Name) {
+			continue
+		}
+
+		versions := make([]string, 0, len(apiGroup.Versions))
+		for _, version := range apiGroup.Versions {
+			versions = append(versions, version.Version)
+		}
+
+		groups = append(groups, map[string]interface{}{
+			"name":              apiGroup.Name,
+			"preferred_version": apiGroup.PreferredVersion.Version,
+			"versions":          versions,
+		})
+	}
+
+	resources := make([]map[string]interface{}, 0)
+	apiVersions := make([]string, 0, len(apiResources))
+	for _, resourceList := range apiResources {
+		groupVersion, err := k8sschema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			log.Printf("[WARN] skipping api resources with invalid group version %s: %+v", resourceList.GroupVersion, err)
+			continue
+		}
+
+		if !includeGroup(groupVersion.Group) {
+			continue
+		}
+		apiVersions = append(apiVersions, resourceList.GroupVersion)
+
+		for _, apiResource := range resourceList.APIResources {
+			if strings.Contains(apiResource.Name, "/") {
+				continue
+			}
+
+			resources = append(resources, map[string]interface{}{
+				"name":        apiResource.Name,
+				"kind":        apiResource.Kind,
+				"group":       groupVersion.Group,
+				"version":     groupVersion.Version,
+				"api_version": resourceList.GroupVersion,
+				"namespaced":  apiResource.Namespaced,
+				"verbs":       []string(apiResource.Verbs),
+				"short_names": apiResource.ShortNames,
+			})
+		}
+	}
+
+	sort.Strings(apiVersions)
+	return groups, resources, apiVersions
+}
@@ -0,0 +1,105 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/stretchr/testify/assert"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFlattenAPIResources(t *testing.T) {
+	apiGroups := []*meta_v1.APIGroup{
+		{
+			Name:             "",
+			Versions:         []meta_v1.GroupVersionForDiscovery{{GroupVersion: "v1", Version: "v1"}},
+			PreferredVersion: meta_v1.GroupVersionForDiscovery{GroupVersion: "v1", Version: "v1"},
+		},
+		{
+			Name: "policy",
+			Versions: []meta_v1.GroupVersionForDiscovery{
+				{GroupVersion: "policy/v1", Version: "v1"},
+				{GroupVersion: "policy/v1beta1", Version: "v1beta1"},
+			},
+			PreferredVersion: meta_v1.GroupVersionForDiscovery{GroupVersion: "policy/v1", Version: "v1"},
+		},
+	}
+
+	apiResources := []*meta_v1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []meta_v1.APIResource{
+				{Name: "pods", Kind: "Pod", Namespaced: true, Verbs: []string{"get", "list"}, ShortNames: []string{"po"}},
+				{Name: "pods/log", Kind: "Pod", Namespaced: true, Verbs: []string{"get"}},
+			},
+		},
+		{
+			GroupVersion: "policy/v1",
+			APIResources: []meta_v1.APIResource{
+				{Name: "poddisruptionbudgets", Kind: "PodDisruptionBudget", Namespaced: true, Verbs: []string{"get"}, ShortNames: []string{"pdb"}},
+			},
+		},
+		{
+			GroupVersion: "policy/v1beta1",
+			APIResources: []meta_v1.APIResource{
+				{Name: "poddisruptionbudgets", Kind: "PodDisruptionBudget", Namespaced: true, Verbs: []string{"get"}},
+			},
+		},
+	}
+
+	groups, resources, apiVersions := flattenAPIResources(apiGroups, apiResources, nil)
+	assert.Len(t, groups, 2)
+	assert.Len(t, resources, 3)
+	assert.Equal(t, []string{"policy/v1", "policy/v1beta1", "v1"}, apiVersions)
+	assert.Equal(t, map[string]interface{}{
+		"name":        "pods",
+		"kind":        "Pod",
+		"group":       "",
+		"version":     "v1",
+		"api_version": "v1",
+		"namespaced":  true,
+		"verbs":       []string{"get", "list"},
+		"short_names": []string{"po"},
+	}, resources[0])
+
+	policy := "policy"
+	groups, resources, apiVersions = flattenAPIResources(apiGroups, apiResources, &policy)
+	assert.Equal(t, []map[string]interface{}{
+		{"name": "policy", "preferred_version": "v1", "versions": []string{"v1", "v1beta1"}},
+	}, groups)
+	assert.Len(t, resources, 2)
+	assert.Equal(t, []string{"policy/v1", "policy/v1beta1"}, apiVersions)
+
+	core := ""
+	groups, resources, apiVersions = flattenAPIResources(apiGroups, apiResources, &core)
+	assert.Len(t, groups, 1)
+	assert.Len(t, resources, 1)
+	assert.Equal(t, []string{"v1"}, apiVersions)
+}
+
+func TestAccKubectlDataSourceAPIResources_group(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+data "kubectl_api_resources" "apps" {
+	group = "apps"
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.kubectl_api_resources.apps", "groups.#", "1"),
+					resource.TestCheckResourceAttr("data.kubectl_api_resources.apps", "groups.0.name", "apps"),
+					resource.TestCheckResourceAttr("data.kubectl_api_resources.apps", "groups.0.preferred_version", "v1"),
+					resource.TestCheckTypeSetElemAttr("data.kubectl_api_resources.apps", "api_versions.*", "apps/v1"),
+					resource.TestCheckTypeSetElemNestedAttrs("data.kubectl_api_resources.apps", "resources.*", map[string]string{
+						"kind":       "Deployment",
+						"name":       "deployments",
+						"namespaced": "true",
+					}),
+				),
+			},
+		},
+	})
+}
@@ -0,0 +1,141 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/gavinbunney/terraform-provider-kubectl/flatten"
+	"github.com/gavinbunney/terraform-provider-kubectl/yaml"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	meta_v1_unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func dataSourceKubectlManifest() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceKubectlManifestRead,
+		Schema: map[string]*schema.Schema{
+			"kubeconfig_context": dataSourceKubeconfigContextSchema,
+			"api_version": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"kind": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"namespace": {
+				Type:        schema.TypeString,
+				Description: "Namespace of the object. Defaults to `default` for namespaced kinds.",
+				Optional:    true,
+			},
+			"sensitive_fields": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString, ValidateFunc: validateFieldSelector},
+				Description: "List of yaml keys with sensitive values. Set these for fields which you want obfuscated in the output",
+				Optional:    true,
+			},
+			"ignore_not_found": {
+				Type:        schema.TypeBool,
+				Description: "Default false. Setting to true will return empty values rather than an error when the object does not exist.",
+				Optional:    true,
+				Default:     false,
+			},
+			"found": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"uid": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"yaml": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"json": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"flattened": {
+				Type:     schema.TypeMap,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceKubectlManifestRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider, err := getContextProvider(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	raw := &meta_v1_unstruct.Unstructured{}
+	raw.SetAPIVersion(d.Get("api_version").(string))
+	raw.SetKind(d.Get("kind").(string))
+	raw.SetName(d.Get("name").(string))
+	raw.SetNamespace(d.Get("namespace").(string))
+	manifest := yaml.NewFromUnstructured(raw)
+
+	restClient := getRestClientFromUnstructured(manifest, provider)
+	if restClient.Error != nil {
+		return diag.FromErr(fmt.Errorf("failed to create kubernetes rest client for read of resource: %+v", restClient.Error))
+	}
+
+	d.SetId(getSelfLink(provider, manifest))
+
+	metaObjLiveRaw, err := restClient.ResourceInterface.Get(ctx, manifest.GetName(), meta_v1.GetOptions{})
+	if errors.IsGone(err) || errors.IsNotFound(err) {
+		if !d.Get("ignore_not_found").(bool) {
+			return diag.FromErr(fmt.Errorf("%v not found in kubernetes", manifest))
+		}
+
+		log.Printf("[DEBUG] %v not found, returning empty values", manifest)
+		_ = d.Set("found", false)
+		_ = d.Set("uid", "")
+		_ = d.Set("yaml", "")
+		_ = d.Set("json", "")
+		_ = d.Set("flattened", map[string]string{})
+		return nil
+	}
+
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("%v failed to get resource from kubernetes: %+v", manifest, err))
+	}
+
+	metaObjLive := yaml.NewFromUnstructured(metaObjLiveRaw)
+	meta_v1_unstruct.RemoveNestedField(metaObjLive.Raw.Object, "metadata", "managedFields")
+
+	if err := obfuscateSensitiveFields(metaObjLive, getSensitiveFields(d, metaObjLive)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	yamlBody, err := metaObjLive.AsYAML()
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("%v failed to convert to yaml: %+v", manifest, err))
+	}
+
+	jsonBody, err := json.Marshal(metaObjLive.Raw.Object)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("%v failed to convert to json: %+v", manifest, err))
+	}
+
+	_ = d.Set("found", true)
+	_ = d.Set("uid", metaObjLive.GetUID())
+	_ = d.Set("yaml", yamlBody)
+	_ = d.Set("json", string(jsonBody))
+	_ = d.Set("flattened", flatten.Flatten(metaObjLive.Raw.Object))
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package kubernetes
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccKubectlDataSourceManifest_basic(t *testing.T) {
+	config := `
+resource "kubectl_manifest" "test" {
+	yaml_body = <<YAML
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: data-source-manifest
+  namespace: default
+data:
+  key: value
+YAML
+}
+
+data "kubectl_manifest" "test" {
+	api_version = "v1"
+	kind        = "ConfigMap"
+	name        = "data-source-manifest"
+	namespace   = "default"
+
+	depends_on = [kubectl_manifest.test]
+}
+
+data "kubectl_manifest" "missing" {
+	api_version      = "v1"
+	kind             = "ConfigMap"
+	name             = "data-source-manifest-missing"
+	ignore_not_found = true
+}
+`
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckkubectlDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.kubectl_manifest.test", "id", "/api/v1/namespaces/default/configmaps/data-source-manifest"),
+					resource.TestCheckResourceAttr("data.kubectl_manifest.test", "found", "true"),
+					resource.TestCheckResourceAttr("data.kubectl_manifest.test", "flattened.data.key", "value"),
+					resource.TestMatchResourceAttr("data.kubectl_manifest.test", "yaml", regexp.MustCompile(`(?m)^  key: value$`)),
+					resource.TestMatchResourceAttr("data.kubectl_manifest.test", "json", regexp.MustCompile(`"data":\{"key":"value"\}`)),
+					resource.TestCheckResourceAttrPair("data.kubectl_manifest.test", "uid", "kubectl_manifest.test", "uid"),
+					resource.TestCheckResourceAttr("data.kubectl_manifest.missing", "found", "false"),
+					resource.TestCheckResourceAttr("data.kubectl_manifest.missing", "yaml", ""),
+				),
+			},
+		},
+	})
+}
+
+func TestAccKubectlDataSourceManifest_notFound(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+data "kubectl_manifest" "missing" {
+	api_version = "v1"
+	kind        = "ConfigMap"
+	name        = "data-source-manifest-missing"
+}
+`,
+				ExpectError: regexp.MustCompile("not found in kubernetes"),
+			},
+		},
+	})
+}
@@ -0,0 +1,206 @@
+package kubernetes
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gavinbunney/terraform-provider-kubectl/yaml"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	meta_v1_unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// objectsListPageSize is the number of objects requested from kubernetes in each page
+const objectsListPageSize = 500
+
+func dataSourceKubectlObjects() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceKubectlObjectsRead,
+		Schema: map[string]*schema.Schema{
+			"kubeconfig_context": dataSourceKubeconfigContextSchema,
+			"api_version": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"kind": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"namespace": {
+				Type:          schema.TypeString,
+				Description:   "Namespace to list objects from. Defaults to `default` for namespaced kinds.",
+				Optional:      true,
+				ConflictsWith: []string{"all_namespaces"},
+			},
+			"all_namespaces": {
+				Type:        schema.TypeBool,
+				Description: "Default false. Setting to true will list objects from all namespaces.",
+				Optional:    true,
+				Default:     false,
+			},
+			"label_selector": {
+				Type:        schema.TypeString,
+				Description: "Label selector to filter objects, e.g. `app=nginx,tier!=frontend`.",
+				Optional:    true,
+			},
+			"field_selector": {
+				Type:        schema.TypeString,
+				Description: "Field selector to filter objects, e.g. `status.phase=Running`.",
+				Optional:    true,
+			},
+			"limit": {
+				Type:         schema.TypeInt,
+				Description:  "Maximum number of objects to return. Default 0, returning all objects.",
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"sensitive_fields": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString, ValidateFunc: validateFieldSelector},
+				Description: "List of yaml keys with sensitive values. Set these for fields which you want obfuscated in the output",
+				Optional:    true,
+			},
+			"objects": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"namespace": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"uid": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"yaml": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"manifests": {
+				Type: schema.TypeMap,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceKubectlObjectsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider, err := getContextProvider(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	raw := &meta_v1_unstruct.Unstructured{}
+	raw.SetAPIVersion(d.Get("api_version").(string))
+	raw.SetKind(d.Get("kind").(string))
+	raw.SetNamespace(d.Get("namespace").(string))
+	manifest := yaml.NewFromUnstructured(raw)
+
+	restClient := getRestClientFromUnstructured(manifest, provider)
+	if restClient.Error != nil {
+		return diag.FromErr(fmt.Errorf("failed to create kubernetes rest client for list of resources: %+v", restClient.Error))
+	}
+
+	var client dynamic.ResourceInterface = restClient.ResourceInterface
+	if restClient.Namespaced && d.Get("all_namespaces").(bool) {
+		client = restClient.NamespaceableResourceInterface.Namespace(meta_v1.NamespaceAll)
+	}
+
+	items, err := listObjects(ctx, client, meta_v1.ListOptions{
+		LabelSelector: d.Get("label_selector").(string),
+		FieldSelector: d.Get("field_selector").(string),
+	}, int64(d.Get("limit").(int)))
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to list %s %s: %+v", manifest.GetAPIVersion(), manifest.GetKind(), err))
+	}
+
+	objects := make([]map[string]interface{}, 0, len(items))
+	manifests := make(map[string]string, len(items))
+	ids := make([]string, 0, len(items))
+	for i := range items {
+		object := yaml.NewFromUnstructured(&items[i])
+		if object.GetAPIVersion() == "" {
+			object.Raw.SetAPIVersion(manifest.GetAPIVersion())
+			object.Raw.SetKind(manifest.GetKind())
+		}
+		meta_v1_unstruct.RemoveNestedField(object.Raw.Object, "metadata", "managedFields")
+
+		if err := obfuscateSensitiveFields(object, getSensitiveFields(d, object)); err != nil {
+			return diag.FromErr(err)
+		}
+
+		yamlBody, err := object.AsYAML()
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("%v failed to convert to yaml: %+v", object, err))
+		}
+
+		selfLink := getSelfLink(provider, object)
+		objects = append(objects, map[string]interface{}{
+			"id":        selfLink,
+			"name":      object.GetName(),
+			"namespace": object.GetNamespace(),
+			"uid":       object.GetUID(),
+			"yaml":      yamlBody,
+		})
+		manifests[selfLink] = yamlBody
+		ids = append(ids, selfLink)
+	}
+
+	log.Printf("[DEBUG] listed %d %s %s objects", len(objects), manifest.GetAPIVersion(), manifest.GetKind())
+
+	_ = d.Set("objects", objects)
+	_ = d.Set("manifests", manifests)
+
+	d.SetId(fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(ids, "")))))
+	return nil
+}
+
+// listObjects lists the objects page by page, following the continue tokens until all objects, or the limit, have been returned
+func listObjects(ctx context.Context, client dynamic.ResourceInterface, opts meta_v1.ListOptions, limit int64) ([]meta_v1_unstruct.Unstructured, error) {
+	var items []meta_v1_unstruct.Unstructured
+
+	for {
+		opts.Limit = objectsListPageSize
+		if remaining := limit - int64(len(items)); limit > 0 && remaining < opts.Limit {
+			opts.Limit = remaining
+		}
+
+		list, err := client.List(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, list.Items...)
+		if limit > 0 && int64(len(items)) >= limit {
+			return items[:limit], nil
+		}
+
+		if list.GetContinue() == "" {
+			return items, nil
+		}
+		opts.Continue = list.GetContinue()
+	}
+}
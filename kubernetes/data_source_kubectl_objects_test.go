@@ -0,0 +1,139 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/stretchr/testify/assert"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	meta_v1_unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// pagedResourceClient serves the objects in pages, using the index of the next object as the continue token
+type pagedResourceClient struct {
+	dynamic.ResourceInterface
+	objects  []meta_v1_unstruct.Unstructured
+	requests []meta_v1.ListOptions
+}
+
+func (c *pagedResourceClient) List(ctx context.Context, opts meta_v1.ListOptions) (*meta_v1_unstruct.UnstructuredList, error) {
+	c.requests = append(c.requests, opts)
+
+	start := 0
+	if opts.Continue != "" {
+		start, _ = strconv.Atoi(opts.Continue)
+	}
+
+	end := len(c.objects)
+	if opts.Limit > 0 && start+int(opts.Limit) < end {
+		end = start + int(opts.Limit)
+	}
+
+	list := &meta_v1_unstruct.UnstructuredList{Items: c.objects[start:end]}
+	if end < len(c.objects) {
+		list.SetContinue(strconv.Itoa(end))
+	}
+	return list, nil
+}
+
+func testObjects(count int) []meta_v1_unstruct.Unstructured {
+	objects := make([]meta_v1_unstruct.Unstructured, count)
+	for i := range objects {
+		objects[i].SetName(fmt.Sprintf("object-%d", i))
+	}
+	return objects
+}
+
+func TestListObjects(t *testing.T) {
+	testCases := []struct {
+		description      string
+		count            int
+		limit            int64
+		expectedCount    int
+		expectedRequests int
+	}{
+		{description: "Single page", count: 10, expectedCount: 10, expectedRequests: 1},
+		{description: "Multiple pages", count: 1200, expectedCount: 1200, expectedRequests: 3},
+		{description: "Limit within page", count: 1200, limit: 5, expectedCount: 5, expectedRequests: 1},
+		{description: "Limit across pages", count: 1200, limit: 700, expectedCount: 700, expectedRequests: 2},
+		{description: "Limit above count", count: 10, limit: 50, expectedCount: 10, expectedRequests: 1},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(tcase.description, func(t *testing.T) {
+			client := &pagedResourceClient{objects: testObjects(tcase.count)}
+			items, err := listObjects(context.Background(), client, meta_v1.ListOptions{LabelSelector: "app=test"}, tcase.limit)
+			assert.NoError(t, err)
+			assert.Len(t, items, tcase.expectedCount)
+			assert.Len(t, client.requests, tcase.expectedRequests)
+
+			for i, item := range items {
+				assert.Equal(t, fmt.Sprintf("object-%d", i), item.GetName())
+			}
+			for _, request := range client.requests {
+				assert.Equal(t, "app=test", request.LabelSelector)
+			}
+		})
+	}
+}
+
+func TestAccKubectlDataSourceObjects_labelSelector(t *testing.T) {
+	config := `
+resource "kubectl_manifest" "test" {
+	count     = 3
+	yaml_body = <<YAML
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: data-source-objects-${count.index}
+  namespace: default
+  labels:
+    data-source-objects: "true"
+data:
+  key: value-${count.index}
+YAML
+}
+
+data "kubectl_objects" "test" {
+	api_version    = "v1"
+	kind           = "ConfigMap"
+	all_namespaces = true
+	label_selector = "data-source-objects=true"
+
+	depends_on = [kubectl_manifest.test]
+}
+
+data "kubectl_objects" "limited" {
+	api_version    = "v1"
+	kind           = "ConfigMap"
+	namespace      = "default"
+	label_selector = "data-source-objects=true"
+	field_selector = "metadata.name!=data-source-objects-0"
+	limit          = 1
+
+	depends_on = [kubectl_manifest.test]
+}
+`
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckkubectlDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.kubectl_objects.test", "objects.#", "3"),
+					resource.TestCheckResourceAttr("data.kubectl_objects.test", "manifests.%", "3"),
+					resource.TestCheckResourceAttrSet("data.kubectl_objects.test", "manifests./api/v1/namespaces/default/configmaps/data-source-objects-1"),
+					resource.TestCheckResourceAttr("data.kubectl_objects.limited", "objects.#", "1"),
+					resource.TestCheckResourceAttr("data.kubectl_objects.limited", "objects.0.namespace", "default"),
+				),
+			},
+		},
+	})
+}
@@ -13,6 +13,7 @@ func dataSourceKubectlServerVersion() *schema.Resource {
 	return &schema.Resource{
 		ReadContext: dataSourceKubectlServerVersionRead,
 		Schema: map[string]*schema.Schema{
+			"kubeconfig_context": dataSourceKubeconfigContextSchema,
 			"version": {
 				Type:     schema.TypeString,
 				Computed: true,
@@ -50,13 +51,16 @@ func dataSourceKubectlServerVersion() *schema.Resource {
 }
 
 func dataSourceKubectlServerVersionRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	provider := meta.(*KubeProvider)
+	provider, err := getContextProvider(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	discoveryClient, err := provider.ToDiscoveryClient()
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	discoveryClient.Invalidate()
 	serverVersion, err := discoveryClient.ServerVersion()
 	if err != nil {
 		return diag.FromErr(err)
@@ -3,6 +3,7 @@ package kubernetes
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -12,10 +13,12 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/mitchellh/go-homedir"
 
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -24,6 +27,8 @@ import (
 	k8sresource "k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/client-go/discovery"
 	diskcached "k8s.io/client-go/discovery/cached/disk"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	restclient "k8s.io/client-go/rest"
@@ -42,6 +47,91 @@ func Provider() *schema.Provider {
 				DefaultFunc: func() (interface{}, error) { return 1, nil },
 				Description: "Defines the number of attempts any create/update action will take",
 			},
+			"apply_retry_initial_interval": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("KUBECTL_PROVIDER_APPLY_RETRY_INITIAL_INTERVAL", defaultApplyRetryInitialInterval.String()),
+				ValidateFunc: validateDuration,
+				Description:  "Interval before the first retry of a failed create/update action, e.g. 5s. Default to 3s.",
+			},
+			"apply_retry_max_interval": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("KUBECTL_PROVIDER_APPLY_RETRY_MAX_INTERVAL", defaultApplyRetryMaxInterval.String()),
+				ValidateFunc: validateDuration,
+				Description:  "Maximum interval between the retries of a failed create/update action, which back off exponentially, e.g. 1m. Default to 30s.",
+			},
+			"dry_run_on_plan": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("KUBECTL_PROVIDER_DRY_RUN_ON_PLAN", false),
+				Description: "Perform a server-side dry-run apply of all manifests during plan, surfacing any errors from the server.",
+			},
+			"field_manager": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("KUBECTL_PROVIDER_FIELD_MANAGER", defaultFieldManager),
+				Description: "Default field manager name used for server-side apply.",
+			},
+			"native_apply": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("KUBECTL_PROVIDER_NATIVE_APPLY", false),
+				Description: "Apply manifests with the kubernetes API directly, rather than through kubectl apply, without writing them to temporary files.",
+			},
+			"discovery_cache_dir": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("KUBECTL_PROVIDER_DISCOVERY_CACHE_DIR", ""),
+				Description: "Directory to cache the API resources discovered from the cluster in. Defaults to the kubectl cache in ~/.kube.",
+			},
+			"discovery_cache_ttl": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("KUBECTL_PROVIDER_DISCOVERY_CACHE_TTL", "10m"),
+				ValidateFunc: validateDuration,
+				Description:  "Duration the API resources cached on disk are used for before being discovered again. Default to 10m.",
+			},
+			"discovery_cache_mode": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("KUBECTL_PROVIDER_DISCOVERY_CACHE_MODE", discoveryCacheModeDisk),
+				ValidateFunc: validation.StringInSlice([]string{discoveryCacheModeDisk, discoveryCacheModeMemory}, false),
+				Description:  "Default to disk. Setting to memory only caches the API resources discovered from the cluster in memory, without writing them to disk.",
+			},
+			"qps": {
+				Type:        schema.TypeFloat,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("KUBECTL_PROVIDER_QPS", 100.0),
+				Description: "Maximum queries per second to the kubernetes API from each client of the provider. Default to 100.",
+			},
+			"burst": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("KUBECTL_PROVIDER_BURST", 100),
+				Description: "Maximum burst of queries to the kubernetes API, above the qps, from each client of the provider. Default to 100.",
+			},
+			"request_timeout": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("KUBECTL_PROVIDER_REQUEST_TIMEOUT", "0s"),
+				ValidateFunc: validateDuration,
+				Description:  "Timeout of each request to the kubernetes API, e.g. 30s. Default to 0s, which does not time out.",
+			},
+			"discovery_timeout": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("KUBECTL_PROVIDER_DISCOVERY_TIMEOUT", defaultDiscoveryTimeout.String()),
+				ValidateFunc: validateDuration,
+				Description:  "Timeout to discover the API resources of the cluster for each manifest, e.g. 2m. Default to 1m.",
+			},
+			"import_mode": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("KUBECTL_PROVIDER_IMPORT_MODE", importModeDefault),
+				ValidateFunc: validation.StringInSlice([]string{importModeDefault, importModeMinimal}, false),
+				Description:  "Default to default. Setting to minimal will only import the fields owned by apply field managers into the yaml_body.",
+			},
 			"host": {
 				Type:        schema.TypeString,
 				Optional:    true,
@@ -179,10 +269,15 @@ func Provider() *schema.Provider {
 			"kubectl_path_documents":      dataSourceKubectlPathDocuments(),
 			"kubectl_server_version":      dataSourceKubectlServerVersion(),
 			"kubectl_kustomize_documents": dataSourceKubectlKustomizeDocuments(),
+			"kubectl_manifest":            dataSourceKubectlManifest(),
+			"kubectl_objects":             dataSourceKubectlObjects(),
+			"kubectl_api_resources":       dataSourceKubectlAPIResources(),
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
+			"kubectl_apply_set":      resourceKubectlApplySet(),
 			"kubectl_manifest":       resourceKubectlManifest(),
+			"kubectl_manifests":      resourceKubectlManifests(),
 			"kubectl_server_version": resourceKubectlServerVersion(),
 		},
 	}
@@ -201,9 +296,255 @@ func Provider() *schema.Provider {
 }
 
 type KubeProvider struct {
-	MainClientset       *kubernetes.Clientset
-	RestConfig          restclient.Config
-	AggregatorClientset *aggregator.Clientset
+	DryRunOnPlan bool
+	FieldManager string
+	ImportMode   string
+	NativeApply  bool
+
+	// providerConfig and terraformVersion are kept to lazily create the clients of the provider, and the providers of
+	// other kubeconfig contexts, when an operation first needs the cluster
+	providerConfig    *schema.ResourceData
+	terraformVersion  string
+	kubeconfigContext string
+	configUnknown     bool
+
+	// the clients are shared by all operations of the provider, so the discovery and rest mapping of the cluster are
+	// only fetched once rather than for every manifest
+	clientsLock         sync.Mutex
+	restConfig          *restclient.Config
+	mainClientset       kubernetes.Interface
+	aggregatorClientset *aggregator.Clientset
+	dynamicClient       dynamic.Interface
+	discoveryClient     discovery.CachedDiscoveryInterface
+	restMapper          meta.RESTMapper
+	deferredRESTMapper  *restmapper.DeferredDiscoveryRESTMapper
+
+	// discoveryGeneration counts the refreshes of the discovery cache, so operations concurrently missing a kind
+	// refresh it once
+	discoveryLock       sync.Mutex
+	discoveryGeneration uint64
+
+	contextsLock sync.Mutex
+	contexts     map[string]*KubeProvider
+}
+
+// errProviderConfigUnknown is returned when an operation needs the cluster during plan, but the provider configuration
+// has values which are only known after apply
+var errProviderConfigUnknown = errors.New("the provider configuration has values which are unknown until apply, such as the host or credentials of a cluster created in the same apply, so the cluster can't be reached during plan")
+
+// errDiscoveryTimeout is returned when the API resources of the cluster are not discovered within the discovery_timeout
+var errDiscoveryTimeout = errors.New("timed out fetching resources from discovery client")
+
+// ConfigUnknown returns whether the provider configuration has values which are unknown until apply, in which case
+// operations needing the cluster during plan keep their prior state, or mark their values as unknown.
+func (p *KubeProvider) ConfigUnknown() bool {
+	return p.configUnknown
+}
+
+// configureClients creates the clients of the provider on first use, so the provider can be configured before the
+// cluster exists, and only operations which need the cluster fail when the configuration is incomplete
+func (p *KubeProvider) configureClients() error {
+	p.clientsLock.Lock()
+	defer p.clientsLock.Unlock()
+
+	if p.restConfig != nil {
+		return nil
+	}
+
+	if p.configUnknown {
+		return errProviderConfigUnknown
+	}
+
+	if p.providerConfig == nil {
+		return fmt.Errorf("the provider is not configured")
+	}
+
+	cfg, contextName, err := initializeConfiguration(p.providerConfig, p.kubeconfigContext)
+	if err != nil {
+		return err
+	}
+
+	cfg.QPS = float32(p.providerConfig.Get("qps").(float64))
+	cfg.Burst = p.providerConfig.Get("burst").(int)
+	if cfg.Timeout, err = time.ParseDuration(p.providerConfig.Get("request_timeout").(string)); err != nil {
+		return fmt.Errorf("invalid request_timeout: %+v", err)
+	}
+
+	// Overriding with static configuration
+	cfg.UserAgent = fmt.Sprintf("HashiCorp/1.0 Terraform/%s", p.terraformVersion)
+
+	k, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to configure: %s", err)
+	}
+
+	a, err := aggregator.NewForConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to configure: %s", err)
+	}
+
+	dc, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to configure: %s", err)
+	}
+
+	discoveryClient, err := newCachedDiscoveryClient(p.providerConfig, cfg, contextName)
+	if err != nil {
+		return fmt.Errorf("failed to configure: %s", err)
+	}
+
+	p.restConfig = cfg
+	p.mainClientset = k
+	p.aggregatorClientset = a
+	p.dynamicClient = dc
+	p.discoveryClient = discoveryClient
+	p.deferredRESTMapper = restmapper.NewDeferredDiscoveryRESTMapper(discoveryClient)
+	p.restMapper = restmapper.NewShortcutExpander(p.deferredRESTMapper, discoveryClient, nil)
+	return nil
+}
+
+// newCachedDiscoveryClient returns a discovery client caching the API resources of the cluster in memory, backed by a
+// discovery cache on disk for the host and kubeconfig context unless the discovery_cache_mode is memory
+func newCachedDiscoveryClient(d *schema.ResourceData, cfg *restclient.Config, contextName string) (discovery.CachedDiscoveryInterface, error) {
+	if d.Get("discovery_cache_mode").(string) == discoveryCacheModeMemory {
+		discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		return memory.NewMemCacheClient(discoveryClient), nil
+	}
+
+	// the cache directories default to those of kubectl
+	home, _ := homedir.Dir()
+	discoveryCacheDir := filepath.Join(home, ".kube", "cache", "discovery")
+	httpCacheDir := filepath.Join(home, ".kube", "http-cache")
+	if v, ok := d.Get("discovery_cache_dir").(string); ok && v != "" {
+		cacheDir, err := homedir.Expand(v)
+		if err != nil {
+			return nil, err
+		}
+
+		discoveryCacheDir = filepath.Join(cacheDir, "discovery")
+		httpCacheDir = filepath.Join(cacheDir, "http")
+	}
+
+	ttl, err := time.ParseDuration(d.Get("discovery_cache_ttl").(string))
+	if err != nil {
+		return nil, fmt.Errorf("invalid discovery_cache_ttl: %+v", err)
+	}
+
+	discoveryCacheDir = computeDiscoverCacheDir(discoveryCacheDir, cfg.Host, contextName)
+	log.Printf("[DEBUG] Using discovery cache: %s", discoveryCacheDir)
+	diskClient, err := diskcached.NewCachedDiscoveryClientForConfig(cfg, discoveryCacheDir, httpCacheDir, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	return memory.NewMemCacheClient(diskClient), nil
+}
+
+// DiscoveryGeneration returns the number of refreshes of the discovery cache, to pass to RefreshDiscovery
+func (p *KubeProvider) DiscoveryGeneration() uint64 {
+	p.discoveryLock.Lock()
+	defer p.discoveryLock.Unlock()
+	return p.discoveryGeneration
+}
+
+// RefreshDiscovery invalidates the discovery cache and resets the rest mapper when a kind isn't found, such as a CRD
+// created by another resource. The cache is only invalidated if it hasn't been refreshed since the generation was read,
+// so operations concurrently missing kinds share a single refresh.
+func (p *KubeProvider) RefreshDiscovery(generation uint64) error {
+	discoveryClient, err := p.ToDiscoveryClient()
+	if err != nil {
+		return err
+	}
+
+	p.discoveryLock.Lock()
+	defer p.discoveryLock.Unlock()
+
+	if generation != p.discoveryGeneration {
+		log.Printf("[DEBUG] Discovery cache already refreshed")
+		return nil
+	}
+
+	log.Printf("[DEBUG] Refreshing discovery cache")
+	discoveryClient.Invalidate()
+
+	// the rest mapper only resets itself on a miss while the discovery cache is stale, which it no longer is once the
+	// operation refreshing it has fetched the resources again, so would keep missing kinds such as newly created CRDs
+	if p.deferredRESTMapper != nil {
+		p.deferredRESTMapper.Reset()
+	}
+
+	p.discoveryGeneration++
+	return nil
+}
+
+// DiscoveryTimeout returns the time to wait for the API resources of the cluster to be discovered
+func (p *KubeProvider) DiscoveryTimeout() time.Duration {
+	if p.providerConfig == nil {
+		return defaultDiscoveryTimeout
+	}
+
+	timeout, err := time.ParseDuration(p.providerConfig.Get("discovery_timeout").(string))
+	if err != nil || timeout <= 0 {
+		return defaultDiscoveryTimeout
+	}
+	return timeout
+}
+
+// ToClientset returns the kubernetes clientset of the provider, creating it on first use
+func (p *KubeProvider) ToClientset() (kubernetes.Interface, error) {
+	if err := p.configureClients(); err != nil {
+		return nil, err
+	}
+	return p.mainClientset, nil
+}
+
+// ToAggregatorClientset returns the kube-aggregator clientset of the provider, creating it on first use
+func (p *KubeProvider) ToAggregatorClientset() (*aggregator.Clientset, error) {
+	if err := p.configureClients(); err != nil {
+		return nil, err
+	}
+	return p.aggregatorClientset, nil
+}
+
+// ToDynamicClient returns the dynamic client of the provider, creating it on first use
+func (p *KubeProvider) ToDynamicClient() (dynamic.Interface, error) {
+	if err := p.configureClients(); err != nil {
+		return nil, err
+	}
+	return p.dynamicClient, nil
+}
+
+// ForContext returns the provider for the context of the provider kubeconfig files, whose clients are created on first use.
+// The provider itself is returned when the context is empty.
+func (p *KubeProvider) ForContext(kubeconfigContext string) (*KubeProvider, error) {
+	if kubeconfigContext == "" {
+		return p, nil
+	}
+
+	if p.providerConfig == nil {
+		return nil, fmt.Errorf("unable to use kubeconfig context %s, the provider is not configured", kubeconfigContext)
+	}
+
+	p.contextsLock.Lock()
+	defer p.contextsLock.Unlock()
+
+	if provider, ok := p.contexts[kubeconfigContext]; ok {
+		return provider, nil
+	}
+
+	provider := newKubeProvider(p.providerConfig, p.terraformVersion)
+	provider.kubeconfigContext = kubeconfigContext
+	provider.configUnknown = p.configUnknown
+
+	if p.contexts == nil {
+		p.contexts = map[string]*KubeProvider{}
+	}
+	p.contexts[kubeconfigContext] = provider
+	return provider, nil
 }
 
 var _ k8sresource.RESTClientGetter = &KubeProvider{}
@@ -212,41 +553,68 @@ func (p *KubeProvider) ToRawKubeConfigLoader() clientcmd.ClientConfig {
 	return nil
 }
 
+// ToRESTConfig returns a copy of the rest config of the provider, allowing each func to manipulate it without
+// affecting another func
 func (p *KubeProvider) ToRESTConfig() (*restclient.Config, error) {
-	return &p.RestConfig, nil
+	if err := p.configureClients(); err != nil {
+		return nil, err
+	}
+	cfg := *p.restConfig
+	return &cfg, nil
 }
 
+// ToDiscoveryClient returns the discovery client of the provider, shared by all operations so the API resources of the
+// cluster are cached in memory
 func (p *KubeProvider) ToDiscoveryClient() (discovery.CachedDiscoveryInterface, error) {
-	home, _ := homedir.Dir()
-	var httpCacheDir = filepath.Join(home, ".kube", "http-cache")
-
-	discoveryCacheDir := computeDiscoverCacheDir(filepath.Join(home, ".kube", "cache", "discovery"), p.RestConfig.Host)
-	return diskcached.NewCachedDiscoveryClientForConfig(&p.RestConfig, discoveryCacheDir, httpCacheDir, 10*time.Minute)
+	if err := p.configureClients(); err != nil {
+		return nil, err
+	}
+	return p.discoveryClient, nil
 }
 
+// ToRESTMapper returns the rest mapper of the provider, backed by the shared discovery client
 func (p *KubeProvider) ToRESTMapper() (meta.RESTMapper, error) {
-	discoveryClient, _ := p.ToDiscoveryClient()
-	if discoveryClient != nil {
-		mapper := restmapper.NewDeferredDiscoveryRESTMapper(discoveryClient)
-		expander := restmapper.NewShortcutExpander(mapper, discoveryClient, nil)
-		return expander, nil
+	if err := p.configureClients(); err != nil {
+		return nil, err
 	}
+	return p.restMapper, nil
+}
 
-	return nil, fmt.Errorf("no restmapper")
+// dataSourceKubeconfigContextSchema selects the context of the provider kubeconfig files a data source reads from
+var dataSourceKubeconfigContextSchema = &schema.Schema{
+	Type:        schema.TypeString,
+	Description: "Context of the provider kubeconfig files to read from. Defaults to the provider configuration.",
+	Optional:    true,
 }
 
-var kubectlApplyRetryCount uint64
+// getContextProvider returns the provider for the kubeconfig_context of the resource or data source
+func getContextProvider(d resourceDataGetter, meta interface{}) (*KubeProvider, error) {
+	kubeconfigContext, _ := d.Get("kubeconfig_context").(string)
+	return meta.(*KubeProvider).ForContext(kubeconfigContext)
+}
 
-func providerConfigure(d *schema.ResourceData, terraformVersion string) (interface{}, diag.Diagnostics) {
+const (
+	// discoveryCacheModeDisk caches the discovered API resources on disk, shared with kubectl and later runs
+	discoveryCacheModeDisk = "disk"
+	// discoveryCacheModeMemory caches the discovered API resources in memory for the run of the provider
+	discoveryCacheModeMemory = "memory"
+)
 
-	cfg, err := initializeConfiguration(d)
-	if err != nil {
-		return nil, diag.FromErr(err)
-	}
+// defaultDiscoveryTimeout is the time to wait for the API resources of the cluster to be discovered
+const defaultDiscoveryTimeout = 60 * time.Second
 
-	if cfg == nil {
-		cfg = &restclient.Config{}
-	}
+const (
+	// defaultApplyRetryInitialInterval is the interval before the first retry of a failed apply
+	defaultApplyRetryInitialInterval = 3 * time.Second
+	// defaultApplyRetryMaxInterval is the maximum interval between the retries of a failed apply
+	defaultApplyRetryMaxInterval = 30 * time.Second
+)
+
+var kubectlApplyRetryCount uint64
+var kubectlApplyRetryInitialInterval = defaultApplyRetryInitialInterval
+var kubectlApplyRetryMaxInterval = defaultApplyRetryMaxInterval
+
+func providerConfigure(d *schema.ResourceData, terraformVersion string) (interface{}, diag.Diagnostics) {
 
 	kubectlApplyRetryCount = uint64(d.Get("apply_retry_count").(int))
 	if os.Getenv("KUBECTL_PROVIDER_APPLY_RETRY_COUNT") != "" {
@@ -254,20 +622,12 @@ func providerConfigure(d *schema.ResourceData, terraformVersion string) (interfa
 		kubectlApplyRetryCount = uint64(applyEnvValue)
 	}
 
-	cfg.QPS = 100.0
-	cfg.Burst = 100
-
-	// Overriding with static configuration
-	cfg.UserAgent = fmt.Sprintf("HashiCorp/1.0 Terraform/%s", terraformVersion)
-
-	k, err := kubernetes.NewForConfig(cfg)
-	if err != nil {
-		return nil, diag.FromErr(fmt.Errorf("failed to configure: %s", err))
+	var err error
+	if kubectlApplyRetryInitialInterval, err = time.ParseDuration(d.Get("apply_retry_initial_interval").(string)); err != nil {
+		return nil, diag.Errorf("invalid apply_retry_initial_interval: %+v", err)
 	}
-
-	a, err := aggregator.NewForConfig(cfg)
-	if err != nil {
-		return nil, diag.FromErr(fmt.Errorf("failed to configure: %s", err))
+	if kubectlApplyRetryMaxInterval, err = time.ParseDuration(d.Get("apply_retry_max_interval").(string)); err != nil {
+		return nil, diag.Errorf("invalid apply_retry_max_interval: %+v", err)
 	}
 
 	// inject our own error handler into the k8s runtime so we can log correctly into provider logs
@@ -291,16 +651,33 @@ func providerConfigure(d *schema.ResourceData, terraformVersion string) (interfa
 		}
 	}
 
-	// dereference config to create a shallow copy, allowing each func
-	// to manipulate the state without affecting another func
+	// the clients are created when an operation first needs the cluster, as the provider is configured during plan
+	// before a cluster created in the same apply exists
+	provider := newKubeProvider(d, terraformVersion)
+	if !d.GetRawConfig().IsWhollyKnown() {
+		log.Printf("[WARN] Provider configuration has values which are unknown until apply, the cluster won't be reached during plan")
+		provider.configUnknown = true
+	}
+
+	return provider, nil
+}
+
+// newKubeProvider returns the provider for the configuration, without creating its clients
+func newKubeProvider(d *schema.ResourceData, terraformVersion string) *KubeProvider {
 	return &KubeProvider{
-		MainClientset:       k,
-		RestConfig:          *cfg,
-		AggregatorClientset: a,
-	}, nil
+		DryRunOnPlan:     d.Get("dry_run_on_plan").(bool),
+		FieldManager:     d.Get("field_manager").(string),
+		ImportMode:       d.Get("import_mode").(string),
+		NativeApply:      d.Get("native_apply").(bool),
+		providerConfig:   d,
+		terraformVersion: terraformVersion,
+	}
 }
 
-func initializeConfiguration(d *schema.ResourceData) (*restclient.Config, error) {
+// initializeConfiguration builds the rest config from the provider configuration, returning it along with the name of the
+// kubeconfig context used, if any. When kubeconfigContext is set, the context is loaded from the kubeconfig files without
+// applying the static cluster and credential overrides.
+func initializeConfiguration(d *schema.ResourceData, kubeconfigContext string) (*restclient.Config, string, error) {
 	overrides := &clientcmd.ConfigOverrides{}
 	loader := &clientcmd.ClientConfigLoadingRules{}
 
@@ -318,7 +695,7 @@ func initializeConfiguration(d *schema.ResourceData) (*restclient.Config, error)
 		configPaths = filepath.SplitList(v)
 	}
 
-	if v, ok := d.GetOk("exec"); ok {
+	if v, ok := d.GetOk("exec"); ok && kubeconfigContext == "" {
 		exec := &clientcmdapi.ExecConfig{
 			InteractiveMode: clientcmdapi.IfAvailableExecInteractiveMode,
 		}
@@ -330,7 +707,7 @@ func initializeConfiguration(d *schema.ResourceData) (*restclient.Config, error)
 				exec.Env = append(exec.Env, clientcmdapi.ExecEnvVar{Name: kk, Value: vv.(string)})
 			}
 		} else {
-			return nil, fmt.Errorf("Failed to parse exec")
+			return nil, "", fmt.Errorf("Failed to parse exec")
 		}
 		overrides.AuthInfo.Exec = exec
 	} else if d.Get("load_config_file").(bool) && len(configPaths) > 0 {
@@ -338,7 +715,7 @@ func initializeConfiguration(d *schema.ResourceData) (*restclient.Config, error)
 		for _, p := range configPaths {
 			path, err := homedir.Expand(p)
 			if err != nil {
-				return nil, err
+				return nil, "", err
 			}
 
 			log.Printf("[DEBUG] Using kubeconfig: %s", path)
@@ -356,7 +733,10 @@ func initializeConfiguration(d *schema.ResourceData) (*restclient.Config, error)
 		kubectx, ctxOk := d.GetOk("config_context")
 		authInfo, authInfoOk := d.GetOk("config_context_auth_info")
 		cluster, clusterOk := d.GetOk("config_context_cluster")
-		if ctxOk || authInfoOk || clusterOk {
+		if kubeconfigContext != "" {
+			overrides.CurrentContext = kubeconfigContext
+			log.Printf("[DEBUG] Using kubeconfig context: %q", kubeconfigContext)
+		} else if ctxOk || authInfoOk || clusterOk {
 			ctxSuffix = "; overriden context"
 			if ctxOk {
 				overrides.CurrentContext = kubectx.(string)
@@ -375,9 +755,41 @@ func initializeConfiguration(d *schema.ResourceData) (*restclient.Config, error)
 			}
 			log.Printf("[DEBUG] Using overidden context: %#v", overrides.Context)
 		}
+	} else if kubeconfigContext != "" {
+		return nil, "", fmt.Errorf("unable to use kubeconfig context %s, the provider is not loading any kubeconfig files", kubeconfigContext)
 	}
 
-	// Overriding with static configuration
+	// Overriding with static configuration, which configures the cluster of the provider rather than any context
+	if kubeconfigContext == "" {
+		if err := staticConfigOverrides(d, overrides); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if v, ok := d.GetOk("proxy_url"); ok {
+		overrides.ClusterDefaults.ProxyURL = v.(string)
+	}
+
+	cc := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loader, overrides)
+	cfg, err := cc.ClientConfig()
+	if err != nil && kubeconfigContext != "" {
+		return nil, "", fmt.Errorf("invalid kubeconfig context %s: %+v", kubeconfigContext, err)
+	} else if err != nil {
+		return nil, "", fmt.Errorf("incomplete provider configuration, unable to connect to a cluster: %+v. Set the host and credentials of the cluster, or the config_path of a kubeconfig file", err)
+	}
+
+	contextName := overrides.CurrentContext
+	if contextName == "" {
+		if rawConfig, err := cc.RawConfig(); err == nil {
+			contextName = rawConfig.CurrentContext
+		}
+	}
+
+	return cfg, contextName, nil
+}
+
+// staticConfigOverrides applies the static cluster and credential configuration of the provider to the overrides
+func staticConfigOverrides(d *schema.ResourceData, overrides *clientcmd.ConfigOverrides) error {
 	if v, ok := d.GetOk("insecure"); ok {
 		overrides.ClusterInfo.InsecureSkipTLSVerify = v.(bool)
 	}
@@ -397,7 +809,7 @@ func initializeConfiguration(d *schema.ResourceData) (*restclient.Config, error)
 		defaultTLS := hasCA || hasCert || overrides.ClusterInfo.InsecureSkipTLSVerify
 		host, _, err := restclient.DefaultServerURL(v.(string), "", apimachineryschema.GroupVersion{}, defaultTLS)
 		if err != nil {
-			return nil, fmt.Errorf("Failed to parse host: %s", err)
+			return fmt.Errorf("Failed to parse host: %s", err)
 		}
 
 		overrides.ClusterInfo.Server = host.String()
@@ -414,31 +826,34 @@ func initializeConfiguration(d *schema.ResourceData) (*restclient.Config, error)
 	if v, ok := d.GetOk("token"); ok {
 		overrides.AuthInfo.Token = v.(string)
 	}
-	if v, ok := d.GetOk("proxy_url"); ok {
-		overrides.ClusterDefaults.ProxyURL = v.(string)
-	}
 	if v, ok := d.GetOk("tls_server_name"); ok {
 		overrides.ClusterInfo.TLSServerName = v.(string)
 	}
-
-	cc := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loader, overrides)
-	cfg, err := cc.ClientConfig()
-	if err != nil {
-		log.Printf("[WARN] Invalid provider configuration was supplied. Provider operations likely to fail: %v", err)
-		return nil, nil
-	}
-
-	return cfg, nil
+	return nil
 }
 
 // overlyCautiousIllegalFileCharacters matches characters that *might* not be supported.  Windows is really restrictive, so this is really restrictive
 var overlyCautiousIllegalFileCharacters = regexp.MustCompile(`[^(\w/\.)]`)
 
-// computeDiscoverCacheDir takes the parentDir and the host and comes up with a "usually non-colliding" name.
-func computeDiscoverCacheDir(parentDir, host string) string {
+// computeDiscoverCacheDir takes the parentDir, the host and the kubeconfig context and comes up with a "usually non-colliding" name.
+// Contexts are cached separately, so the API resources discovered by the credentials of one context aren't used by another.
+func computeDiscoverCacheDir(parentDir, host string, contextName string) string {
 	// strip the optional scheme from host if its there:
 	schemelessHost := strings.Replace(strings.Replace(host, "https://", "", 1), "http://", "", 1)
 	// now do a simple collapse of non-AZ09 characters.  Collisions are possible but unlikely.  Even if we do collide the problem is short lived
 	safeHost := overlyCautiousIllegalFileCharacters.ReplaceAllString(schemelessHost, "_")
-	return filepath.Join(parentDir, safeHost)
+	if contextName == "" {
+		return filepath.Join(parentDir, safeHost)
+	}
+
+	safeContext := strings.ReplaceAll(overlyCautiousIllegalFileCharacters.ReplaceAllString(contextName, "_"), "/", "_")
+	return filepath.Join(parentDir, "contexts", safeContext, safeHost)
+}
+
+// validateDuration validates the value is a duration, such as 10m
+func validateDuration(v interface{}, k string) ([]string, []error) {
+	if _, err := time.ParseDuration(v.(string)); err != nil {
+		return nil, []error{fmt.Errorf("%s must be a duration, such as 10m: %+v", k, err)}
+	}
+	return nil, nil
 }
@@ -4,10 +4,22 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery/cached/memory"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	k8stesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
@@ -48,14 +60,17 @@ func testAccCheckkubectlExists(s *terraform.State) error {
 }
 
 func testAccCheckkubectlStatus(s *terraform.State, shouldExist bool) error {
-	provider := testAccProvider.Meta().(*KubeProvider)
+	clientset, err := testAccProvider.Meta().(*KubeProvider).ToClientset()
+	if err != nil {
+		return err
+	}
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "kubectl_manifest" {
 			continue
 		}
 
-		content, err := provider.MainClientset.RESTClient().Get().AbsPath(rs.Primary.ID).DoRaw(context.TODO())
+		content, err := clientset.Discovery().RESTClient().Get().AbsPath(rs.Primary.ID).DoRaw(context.TODO())
 		if (errors.IsNotFound(err) || errors.IsGone(err)) && shouldExist {
 			return fmt.Errorf("Failed to find resource, likely a failure to create occured: %+v %v", err, string(content))
 		}
@@ -65,6 +80,259 @@ func testAccCheckkubectlStatus(s *terraform.State, shouldExist bool) error {
 	return nil
 }
 
+func TestKubeProviderForContext(t *testing.T) {
+	kubeconfig := `
+apiVersion: v1
+kind: Config
+current-context: primary
+clusters:
+- name: primary
+  cluster:
+    server: https://primary.example.com
+- name: secondary
+  cluster:
+    server: https://secondary.example.com
+users:
+- name: admin
+  user:
+    token: secret
+contexts:
+- name: primary
+  context:
+    cluster: primary
+    user: admin
+- name: admin@secondary
+  context:
+    cluster: secondary
+    user: admin
+`
+	configPath := t.TempDir() + "/kubeconfig"
+	require.NoError(t, os.WriteFile(configPath, []byte(kubeconfig), 0600))
+
+	d := schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"config_path": configPath,
+		"host":        "https://override.example.com",
+	})
+
+	provider := newKubeProvider(d, "1.5.0")
+	cfg, err := provider.ToRESTConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "https://override.example.com", cfg.Host)
+
+	defaultProvider, err := provider.ForContext("")
+	require.NoError(t, err)
+	assert.Same(t, provider, defaultProvider)
+
+	// the context is loaded without the static overrides of the provider cluster
+	secondary, err := provider.ForContext("admin@secondary")
+	require.NoError(t, err)
+	cfg, err = secondary.ToRESTConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "https://secondary.example.com", cfg.Host)
+	assert.Equal(t, "secret", cfg.BearerToken)
+
+	cached, err := provider.ForContext("admin@secondary")
+	require.NoError(t, err)
+	assert.Same(t, secondary, cached)
+
+	// contexts are only loaded when an operation first needs the cluster
+	missing, err := provider.ForContext("missing")
+	require.NoError(t, err)
+	_, err = missing.ToRESTConfig()
+	assert.ErrorContains(t, err, "invalid kubeconfig context missing")
+
+	_, contextName, err := initializeConfiguration(d, "")
+	require.NoError(t, err)
+	assert.Equal(t, "primary", contextName)
+	_, contextName, err = initializeConfiguration(d, "admin@secondary")
+	require.NoError(t, err)
+	assert.Equal(t, "admin@secondary", contextName)
+
+	withoutConfigPath := schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"host":             "https://override.example.com",
+		"load_config_file": false,
+	})
+	_, _, err = initializeConfiguration(withoutConfigPath, "primary")
+	assert.ErrorContains(t, err, "not loading any kubeconfig files")
+
+	_, err = (&KubeProvider{}).ForContext("primary")
+	assert.Error(t, err)
+}
+
+func TestKubeProviderLazyClients(t *testing.T) {
+	// an incomplete configuration only fails the operations which need the cluster
+	incomplete := newKubeProvider(schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"load_config_file": false,
+	}), "1.5.0")
+	_, err := incomplete.ToClientset()
+	assert.ErrorContains(t, err, "incomplete provider configuration")
+
+	// a configuration with values unknown until apply can't reach the cluster during plan
+	unknown := newKubeProvider(schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"host": "https://example.com",
+	}), "1.5.0")
+	unknown.configUnknown = true
+	assert.True(t, unknown.ConfigUnknown())
+	_, err = unknown.ToRESTConfig()
+	assert.ErrorIs(t, err, errProviderConfigUnknown)
+	_, err = unknown.ToRESTMapper()
+	assert.ErrorIs(t, err, errProviderConfigUnknown)
+
+	configured := newKubeProvider(schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"host":             "https://example.com",
+		"load_config_file": false,
+	}), "1.5.0")
+	cfg, err := configured.ToRESTConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", cfg.Host)
+
+	// each func receives its own copy of the rest config
+	cfg.Host = "https://changed.example.com"
+	cfg, err = configured.ToRESTConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", cfg.Host)
+}
+
+func TestKubeProviderClientSettings(t *testing.T) {
+	defaults := newKubeProvider(schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"host":             "https://example.com",
+		"load_config_file": false,
+	}), "1.5.0")
+	cfg, err := defaults.ToRESTConfig()
+	require.NoError(t, err)
+	assert.Equal(t, float32(100), cfg.QPS)
+	assert.Equal(t, 100, cfg.Burst)
+	assert.Equal(t, time.Duration(0), cfg.Timeout)
+	assert.Equal(t, time.Minute, defaults.DiscoveryTimeout())
+
+	t.Setenv("KUBECTL_PROVIDER_QPS", "5.5")
+	t.Setenv("KUBECTL_PROVIDER_BURST", "10")
+	t.Setenv("KUBECTL_PROVIDER_DISCOVERY_TIMEOUT", "2m")
+	configured := newKubeProvider(schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"host":             "https://example.com",
+		"load_config_file": false,
+		"request_timeout":  "30s",
+	}), "1.5.0")
+	cfg, err = configured.ToRESTConfig()
+	require.NoError(t, err)
+	assert.Equal(t, float32(5.5), cfg.QPS)
+	assert.Equal(t, 10, cfg.Burst)
+	assert.Equal(t, 30*time.Second, cfg.Timeout)
+	assert.Equal(t, 2*time.Minute, configured.DiscoveryTimeout())
+	assert.Equal(t, time.Minute, (&KubeProvider{}).DiscoveryTimeout())
+}
+
+func TestComputeDiscoverCacheDir(t *testing.T) {
+	assert.Equal(t, filepath.Join("cache", "example.com_6443"), computeDiscoverCacheDir("cache", "https://example.com:6443", ""))
+	assert.Equal(t, filepath.Join("cache", "contexts", "admin_secondary", "example.com_6443"), computeDiscoverCacheDir("cache", "https://example.com:6443", "admin@secondary"))
+	assert.Equal(t, filepath.Join("cache", "contexts", "arn_aws_eks_cluster_main", "example.com"), computeDiscoverCacheDir("cache", "example.com", "arn:aws:eks:cluster/main"))
+}
+
+func TestNewCachedDiscoveryClient(t *testing.T) {
+	cacheDir := t.TempDir()
+	cfg := &restclient.Config{Host: "https://example.com"}
+
+	_, err := newCachedDiscoveryClient(schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"discovery_cache_dir": cacheDir,
+		"discovery_cache_ttl": "1h",
+	}), cfg, "primary")
+	require.NoError(t, err)
+
+	// the memory mode never writes to the cache directory
+	discoveryClient, err := newCachedDiscoveryClient(schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"discovery_cache_dir":  cacheDir,
+		"discovery_cache_mode": "memory",
+	}), cfg, "primary")
+	require.NoError(t, err)
+	assert.False(t, discoveryClient.Fresh())
+	entries, err := os.ReadDir(cacheDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+// countingDiscovery counts the invalidations of the discovery cache
+type countingDiscovery struct {
+	*fakediscovery.FakeDiscovery
+	invalidated atomic.Int32
+}
+
+func (d *countingDiscovery) Fresh() bool {
+	return true
+}
+
+func (d *countingDiscovery) Invalidate() {
+	d.invalidated.Add(1)
+}
+
+func TestKubeProviderRefreshDiscovery(t *testing.T) {
+	discoveryClient := &countingDiscovery{FakeDiscovery: &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{}}}
+	provider := &KubeProvider{restConfig: &restclient.Config{}, discoveryClient: discoveryClient}
+
+	// operations concurrently missing a kind share a single refresh
+	generation := provider.DiscoveryGeneration()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, provider.RefreshDiscovery(generation))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), discoveryClient.invalidated.Load())
+
+	// a later miss refreshes the cache again
+	require.NoError(t, provider.RefreshDiscovery(provider.DiscoveryGeneration()))
+	assert.Equal(t, int32(2), discoveryClient.invalidated.Load())
+
+	// the clients are reused by every operation
+	first, err := provider.ToDiscoveryClient()
+	require.NoError(t, err)
+	second, err := provider.ToDiscoveryClient()
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+}
+
+func TestKubeProviderRefreshDiscovery_restMapper(t *testing.T) {
+	fake := &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{}}
+	fake.Resources = []*meta_v1.APIResourceList{{
+		GroupVersion: "v1",
+		APIResources: []meta_v1.APIResource{{Name: "configmaps", Kind: "ConfigMap", Namespaced: true}},
+	}}
+
+	discoveryClient := memory.NewMemCacheClient(fake)
+	deferredRESTMapper := restmapper.NewDeferredDiscoveryRESTMapper(discoveryClient)
+	provider := &KubeProvider{
+		restConfig:         &restclient.Config{},
+		discoveryClient:    discoveryClient,
+		deferredRESTMapper: deferredRESTMapper,
+		restMapper:         restmapper.NewShortcutExpander(deferredRESTMapper, discoveryClient, nil),
+	}
+
+	mapper, err := provider.ToRESTMapper()
+	require.NoError(t, err)
+
+	widget := k8sschema.GroupKind{Group: "example.com", Kind: "Widget"}
+	_, err = mapper.RESTMapping(widget)
+	assert.True(t, meta.IsNoMatchError(err))
+
+	// a CRD is created by another resource of the same run
+	fake.Resources = append(fake.Resources, &meta_v1.APIResourceList{
+		GroupVersion: "example.com/v1",
+		APIResources: []meta_v1.APIResource{{Name: "widgetz", Kind: "Widget", Namespaced: true}},
+	})
+
+	// the operation missing the kind refreshes discovery and fetches the resources again, so the cache is fresh
+	require.NoError(t, provider.RefreshDiscovery(provider.DiscoveryGeneration()))
+	_, _, err = discoveryClient.ServerGroupsAndResources()
+	require.NoError(t, err)
+	assert.True(t, discoveryClient.Fresh())
+
+	mapping, err := mapper.RESTMapping(widget)
+	require.NoError(t, err)
+	assert.Equal(t, "widgetz", mapping.Resource.Resource)
+}
+
 func TestAccAuthExecPlugin(t *testing.T) {
 	if os.Getenv(resource.EnvTfAcc) == "" {
 		t.Skipf("Acceptance tests skipped unless env '%s' set", resource.EnvTfAcc)
@@ -0,0 +1,621 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/gavinbunney/terraform-provider-kubectl/yaml"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	meta_v1_unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	apiMachineryTypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/kubectl/pkg/cmd/apply"
+)
+
+// applySetTooling identifies this provider as the manager of the apply set, as per the ApplySet specification
+// https://github.com/kubernetes/enhancements/tree/master/keps/sig-cli/3659-kubectl-apply-prune
+var applySetTooling = apply.ApplySetTooling{Name: "terraform-provider-kubectl", Version: "v1"}
+
+func resourceKubectlApplySet() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+			if err := resourceKubectlApplySetApply(ctx, d, meta); err != nil {
+				return diag.FromErr(err)
+			}
+
+			return nil
+		},
+		ReadContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+			if meta.(*KubeProvider).ConfigUnknown() {
+				log.Printf("[WARN] %s provider configuration is unknown until apply, keeping the prior state", d.Id())
+				return nil
+			}
+
+			if err := resourceKubectlApplySetRead(ctx, d, meta); err != nil {
+				return diag.FromErr(err)
+			}
+
+			return nil
+		},
+		UpdateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+			if err := resourceKubectlApplySetApply(ctx, d, meta); err != nil {
+				return diag.FromErr(err)
+			}
+
+			return nil
+		},
+		DeleteContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+			if err := resourceKubectlApplySetDelete(ctx, d, meta); err != nil {
+				return diag.FromErr(err)
+			}
+
+			return nil
+		},
+		CustomizeDiff: func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+			_ = d.SetNew("apply_set_id", applySetID(d.Get("name").(string), d.Get("namespace").(string), d.Get("kind").(string)))
+
+			if !d.Get("prune").(bool) {
+				_ = d.SetNew("pruned_objects", []string{})
+				return nil
+			}
+
+			if !d.NewValueKnown("members") {
+				log.Printf("[TRACE] members value interpolated, pruned objects will be known after apply")
+				_ = d.SetNewComputed("orphaned_objects")
+				_ = d.SetNewComputed("pruned_objects")
+				return nil
+			}
+
+			// objects found during refresh which are not members, along with any members being removed, will be pruned
+			oldMembers, newMembers := d.GetChange("members")
+			prunable := sets.New[string](expandStringList(d.Get("orphaned_objects").([]interface{}))...)
+			prunable.Insert(expandStringList(oldMembers.(*schema.Set).Difference(newMembers.(*schema.Set)).List())...)
+
+			if prunable.Len() > 0 {
+				log.Printf("[TRACE] apply set %s will prune %d objects", d.Id(), prunable.Len())
+				_ = d.SetNewComputed("orphaned_objects")
+				_ = d.SetNew("pruned_objects", sets.List(prunable))
+			} else {
+				_ = d.SetNew("pruned_objects", []string{})
+			}
+
+			return nil
+		},
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Description: "Name of the apply set parent object.",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"namespace": {
+				Type:        schema.TypeString,
+				Description: "Namespace of the apply set parent object.",
+				Optional:    true,
+				Default:     "default",
+				ForceNew:    true,
+			},
+			"kind": {
+				Type:         schema.TypeString,
+				Description:  "Kind of the apply set parent object, either Secret or ConfigMap.",
+				Optional:     true,
+				Default:      "Secret",
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice([]string{"Secret", "ConfigMap"}, false),
+			},
+			"members": {
+				Type:        schema.TypeSet,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "IDs of the kubernetes objects which are members of the apply set, such as kubectl_manifest ids.",
+				Required:    true,
+			},
+			"prune": {
+				Type:        schema.TypeBool,
+				Description: "Default to true. Set this flag to prune objects which are labelled as part of the apply set, but are no longer members.",
+				Optional:    true,
+				Default:     true,
+			},
+			"apply_set_id": {
+				Type:        schema.TypeString,
+				Description: "The apply set id, used as the applyset.kubernetes.io/part-of label value for members.",
+				Computed:    true,
+			},
+			"orphaned_objects": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "IDs of objects labelled as part of the apply set which are not members.",
+				Computed:    true,
+			},
+			"pruned_objects": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "IDs of objects pruned from the apply set. During plan, lists the objects which would be pruned.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+// applySetID computes the id of the apply set for the given parent, as per the ApplySet specification
+func applySetID(name string, namespace string, kind string) string {
+	parent := &apply.ApplySetParentRef{
+		Name:      name,
+		Namespace: namespace,
+		RESTMapping: &meta.RESTMapping{
+			GroupVersionKind: k8sschema.GroupVersionKind{Version: "v1", Kind: kind},
+		},
+	}
+	return apply.NewApplySet(parent, applySetTooling, nil, nil).ID()
+}
+
+// parseSelfLink extracts the resource, namespace and name from a self link, as built by yaml.Manifest.GetSelfLink
+func parseSelfLink(selfLink string) (k8sschema.GroupVersionResource, string, string, error) {
+	gvr := k8sschema.GroupVersionResource{}
+	parts := strings.Split(strings.Trim(selfLink, "/"), "/")
+
+	var rest []string
+	switch {
+	case len(parts) > 2 && parts[0] == "api":
+		gvr.Version = parts[1]
+		rest = parts[2:]
+	case len(parts) > 3 && parts[0] == "apis":
+		gvr.Group = parts[1]
+		gvr.Version = parts[2]
+		rest = parts[3:]
+	default:
+		return gvr, "", "", fmt.Errorf("unable to parse self link: %s", selfLink)
+	}
+
+	switch {
+	case len(rest) == 2:
+		gvr.Resource = rest[0]
+		return gvr, "", rest[1], nil
+	case len(rest) == 4 && rest[0] == "namespaces":
+		gvr.Resource = rest[2]
+		return gvr, rest[1], rest[3], nil
+	default:
+		return gvr, "", "", fmt.Errorf("unable to parse self link: %s", selfLink)
+	}
+}
+
+// resolveSelfLink resolves the resource, namespace and name of an object from its id. The resource of the id is matched
+// against the resources served for its group version, or against the legacy resource names of their kinds, as ids of
+// kubectl_manifest resources may still use the legacy resource name when their state could not be upgraded.
+func resolveSelfLink(discoveryClient discovery.DiscoveryInterface, id string) (k8sschema.GroupVersionResource, string, string, error) {
+	gvr, namespace, name, err := parseSelfLink(id)
+	if err != nil {
+		return gvr, "", "", err
+	}
+
+	resources, err := discoveryClient.ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+	if err != nil {
+		return gvr, "", "", fmt.Errorf("failed to find the resources of %s: %+v", id, err)
+	}
+
+	var legacyMatch string
+	for _, resource := range resources.APIResources {
+		if strings.Contains(resource.Name, "/") {
+			// subresources, such as deployments/status
+			continue
+		}
+
+		if resource.Name == gvr.Resource {
+			return gvr, namespace, name, nil
+		}
+
+		if yaml.LegacyResourceName(resource.Kind) == gvr.Resource {
+			legacyMatch = resource.Name
+		}
+	}
+
+	if legacyMatch == "" {
+		return gvr, "", "", fmt.Errorf("resource %s of %s isn't served by the cluster", gvr.Resource, id)
+	}
+
+	gvr.Resource = legacyMatch
+	return gvr, namespace, name, nil
+}
+
+// formatGroupKind formats the group kind as used in the applyset.kubernetes.io/contains-group-kinds annotation
+func formatGroupKind(gk k8sschema.GroupKind) string {
+	if gk.Group == "" {
+		return gk.Kind
+	}
+	return fmt.Sprintf("%s.%s", gk.Kind, gk.Group)
+}
+
+// parseGroupKinds parses the applyset.kubernetes.io/contains-group-kinds annotation
+func parseGroupKinds(annotation string) []k8sschema.GroupKind {
+	var groupKinds []k8sschema.GroupKind
+	for _, gk := range strings.Split(annotation, ",") {
+		if gk = strings.TrimSpace(gk); gk != "" {
+			groupKinds = append(groupKinds, k8sschema.ParseGroupKind(gk))
+		}
+	}
+	return groupKinds
+}
+
+func splitAnnotationList(annotation string) []string {
+	var values []string
+	for _, v := range strings.Split(annotation, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func applySetParentManifest(d resourceDataGetter) *yaml.Manifest {
+	raw := &meta_v1_unstruct.Unstructured{Object: map[string]interface{}{}}
+	raw.SetAPIVersion("v1")
+	raw.SetKind(d.Get("kind").(string))
+	raw.SetName(d.Get("name").(string))
+	raw.SetNamespace(d.Get("namespace").(string))
+	return yaml.NewFromUnstructured(raw)
+}
+
+// applySetMember is an object within the cluster belonging to an apply set
+type applySetMember struct {
+	ID        string
+	GroupKind k8sschema.GroupKind
+	Resource  k8sschema.GroupVersionResource
+	Namespace string
+	Name      string
+}
+
+// resourceClient returns the client for the resource of the member
+func (m *applySetMember) resourceClient(client dynamic.Interface) dynamic.ResourceInterface {
+	if m.Namespace != "" {
+		return client.Resource(m.Resource).Namespace(m.Namespace)
+	}
+	return client.Resource(m.Resource)
+}
+
+// getApplySetMembers fetches the member objects from kubernetes
+func getApplySetMembers(ctx context.Context, discoveryClient discovery.DiscoveryInterface, client dynamic.Interface, ids []string) ([]*applySetMember, error) {
+	members := make([]*applySetMember, 0, len(ids))
+	for _, id := range ids {
+		gvr, namespace, name, err := resolveSelfLink(discoveryClient, id)
+		if err != nil {
+			return nil, err
+		}
+
+		member := &applySetMember{ID: id, Resource: gvr, Namespace: namespace, Name: name}
+		live, err := member.resourceClient(client).Get(ctx, name, meta_v1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get apply set member %s: %+v", id, err)
+		}
+
+		member.GroupKind = live.GroupVersionKind().GroupKind()
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+// labelApplySetMember adds the applyset.kubernetes.io/part-of label to the member object. As per the ApplySet
+// specification, objects which are already part of another apply set are refused rather than moved to this one.
+func labelApplySetMember(ctx context.Context, client dynamic.Interface, member *applySetMember, applySetId string) error {
+	live, err := member.resourceClient(client).Get(ctx, member.Name, meta_v1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get apply set member %s: %+v", member.ID, err)
+	}
+
+	switch partOf := live.GetLabels()[apply.ApplysetPartOfLabel]; partOf {
+	case applySetId:
+		return nil
+	case "":
+		if err := patchApplySetMemberLabel(ctx, client, member, live.GetResourceVersion(), applySetId); err != nil {
+			return fmt.Errorf("failed to label apply set member %s: %+v", member.ID, err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s is already part of apply set %s, remove it from that apply set first", member.ID, partOf)
+	}
+}
+
+// unlabelApplySetMember removes the applyset.kubernetes.io/part-of label from the member object, if it still exists
+// and is part of the apply set
+func unlabelApplySetMember(ctx context.Context, client dynamic.Interface, member *applySetMember, applySetId string) error {
+	live, err := member.resourceClient(client).Get(ctx, member.Name, meta_v1.GetOptions{})
+	if err == nil {
+		if live.GetLabels()[apply.ApplysetPartOfLabel] != applySetId {
+			return nil
+		}
+		err = patchApplySetMemberLabel(ctx, client, member, live.GetResourceVersion(), nil)
+	}
+
+	if err != nil && !errors.IsNotFound(err) && !errors.IsGone(err) {
+		return fmt.Errorf("failed to remove apply set label from %s: %+v", member.ID, err)
+	}
+	return nil
+}
+
+// patchApplySetMemberLabel sets the applyset.kubernetes.io/part-of label of the member object, removing it when nil.
+// The resource version of the object read before patching is a precondition, so concurrent changes to the label
+// are not overwritten.
+func patchApplySetMemberLabel(ctx context.Context, client dynamic.Interface, member *applySetMember, resourceVersion string, applySetId interface{}) error {
+	metadata := map[string]interface{}{
+		"labels": map[string]interface{}{
+			apply.ApplysetPartOfLabel: applySetId,
+		},
+	}
+	if resourceVersion != "" {
+		metadata["resourceVersion"] = resourceVersion
+	}
+	patch, _ := json.Marshal(map[string]interface{}{"metadata": metadata})
+
+	_, err := member.resourceClient(client).Patch(ctx, member.Name, apiMachineryTypes.MergePatchType, patch, meta_v1.PatchOptions{})
+	return err
+}
+
+// findApplySetOrphans lists all objects labelled as part of the apply set, within the group kinds and namespaces
+// recorded on the parent, and returns those which are not members
+func findApplySetOrphans(ctx context.Context, provider *KubeProvider, client dynamic.Interface, parent *meta_v1_unstruct.Unstructured, memberIds []string) ([]string, error) {
+	applySetId := parent.GetLabels()[apply.ApplySetParentIDLabel]
+	annotations := parent.GetAnnotations()
+
+	namespaces := sets.New[string](parent.GetNamespace())
+	namespaces.Insert(splitAnnotationList(annotations[apply.ApplySetAdditionalNamespacesAnnotation])...)
+
+	isMember := sets.New[string](memberIds...)
+
+	mapper, err := provider.ToRESTMapper()
+	if err != nil {
+		return nil, err
+	}
+
+	var orphans []string
+	for _, gk := range parseGroupKinds(annotations[apply.ApplySetGKsAnnotation]) {
+		mapping, err := mapper.RESTMapping(gk)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find resource for %s: %+v", formatGroupKind(gk), err)
+		}
+
+		var resourceClients []dynamic.ResourceInterface
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			for _, ns := range sets.List(namespaces) {
+				resourceClients = append(resourceClients, client.Resource(mapping.Resource).Namespace(ns))
+			}
+		} else {
+			resourceClients = append(resourceClients, client.Resource(mapping.Resource))
+		}
+
+		for _, resourceClient := range resourceClients {
+			list, err := resourceClient.List(ctx, meta_v1.ListOptions{
+				LabelSelector: fmt.Sprintf("%s=%s", apply.ApplysetPartOfLabel, applySetId),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to list %s in apply set: %+v", formatGroupKind(gk), err)
+			}
+
+			for i := range list.Items {
+				// members may still be identified by the legacy self link until their state is upgraded
+				object := yaml.NewFromUnstructured(&list.Items[i])
+				id := object.GetResourceSelfLink(mapper)
+				if !isMember.Has(id) && !isMember.Has(object.GetSelfLink()) {
+					orphans = append(orphans, id)
+				}
+			}
+		}
+	}
+
+	sort.Strings(orphans)
+	return orphans, nil
+}
+
+func resourceKubectlApplySetApply(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	provider := meta.(*KubeProvider)
+	client, err := provider.ToDynamicClient()
+	if err != nil {
+		return err
+	}
+
+	parentManifest := applySetParentManifest(d)
+	applySetId := applySetID(parentManifest.GetName(), parentManifest.GetNamespace(), parentManifest.GetKind())
+
+	restClient := getRestClientFromUnstructured(parentManifest, provider)
+	if restClient.Error != nil {
+		return fmt.Errorf("%v failed to create kubernetes rest client for apply set parent: %+v", parentManifest, restClient.Error)
+	}
+
+	discoveryClient, err := provider.ToDiscoveryClient()
+	if err != nil {
+		return err
+	}
+
+	memberIds := expandStringList(d.Get("members").(*schema.Set).List())
+	members, err := getApplySetMembers(ctx, discoveryClient, client, memberIds)
+	if err != nil {
+		return err
+	}
+
+	groupKinds := sets.New[string]()
+	namespaces := sets.New[string]()
+	for _, m := range members {
+		groupKinds.Insert(formatGroupKind(m.GroupKind))
+		if m.Namespace != "" && m.Namespace != parentManifest.GetNamespace() {
+			namespaces.Insert(m.Namespace)
+		}
+	}
+
+	// fetch or create the parent
+	parent, err := restClient.ResourceInterface.Get(ctx, parentManifest.GetName(), meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		parent = parentManifest.Raw.DeepCopy()
+		parent.SetLabels(map[string]string{apply.ApplySetParentIDLabel: applySetId})
+		parent.SetAnnotations(map[string]string{apply.ApplySetToolingAnnotation: applySetTooling.String()})
+
+		log.Printf("[INFO] %v creating apply set parent", parentManifest)
+		parent, err = restClient.ResourceInterface.Create(ctx, parent, meta_v1.CreateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("%v failed to get apply set parent: %+v", parentManifest, err)
+	}
+
+	if tooling, ok := parent.GetAnnotations()[apply.ApplySetToolingAnnotation]; ok && tooling != applySetTooling.String() {
+		return fmt.Errorf("%v apply set parent is managed by %s, expected %s", parentManifest, tooling, applySetTooling.String())
+	}
+
+	if parent.GetLabels()[apply.ApplySetParentIDLabel] != applySetId {
+		return fmt.Errorf("%v is not an apply set parent, expected label %s=%s", parentManifest, apply.ApplySetParentIDLabel, applySetId)
+	}
+
+	d.SetId(getSelfLink(provider, yaml.NewFromUnstructured(parent)))
+	_ = d.Set("apply_set_id", applySetId)
+
+	// record a superset of the previous and current group kinds and namespaces before labelling, so an
+	// interrupted apply will still find any orphans on the next run
+	annotations := parent.GetAnnotations()
+	previousGroupKinds := splitAnnotationList(annotations[apply.ApplySetGKsAnnotation])
+	previousNamespaces := splitAnnotationList(annotations[apply.ApplySetAdditionalNamespacesAnnotation])
+	parent, err = updateApplySetParent(ctx, restClient.ResourceInterface, parent,
+		sets.List(groupKinds.Clone().Insert(previousGroupKinds...)),
+		sets.List(namespaces.Clone().Insert(previousNamespaces...)))
+	if err != nil {
+		return err
+	}
+
+	for _, m := range members {
+		if err := labelApplySetMember(ctx, client, m, applySetId); err != nil {
+			return err
+		}
+	}
+
+	if d.Get("prune").(bool) {
+		orphans, err := findApplySetOrphans(ctx, provider, client, parent, memberIds)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range orphans {
+			gvr, namespace, name, err := resolveSelfLink(discoveryClient, id)
+			if err != nil {
+				return err
+			}
+
+			orphan := &applySetMember{ID: id, Resource: gvr, Namespace: namespace, Name: name}
+			log.Printf("[INFO] %v pruning object from apply set %s", id, applySetId)
+			propagationPolicy := meta_v1.DeletePropagationBackground
+			err = orphan.resourceClient(client).Delete(ctx, name, meta_v1.DeleteOptions{PropagationPolicy: &propagationPolicy})
+			if err != nil && !errors.IsNotFound(err) && !errors.IsGone(err) {
+				return fmt.Errorf("failed to prune %s from apply set: %+v", id, err)
+			}
+		}
+
+		// now pruned, the parent only needs to track the current group kinds and namespaces
+		if _, err = updateApplySetParent(ctx, restClient.ResourceInterface, parent, sets.List(groupKinds), sets.List(namespaces)); err != nil {
+			return err
+		}
+
+		_ = d.Set("pruned_objects", orphans)
+	} else {
+		_ = d.Set("pruned_objects", []string{})
+	}
+
+	return resourceKubectlApplySetRead(ctx, d, meta)
+}
+
+func updateApplySetParent(ctx context.Context, client dynamic.ResourceInterface, parent *meta_v1_unstruct.Unstructured, groupKinds []string, namespaces []string) (*meta_v1_unstruct.Unstructured, error) {
+	annotations := parent.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[apply.ApplySetToolingAnnotation] = applySetTooling.String()
+	annotations[apply.ApplySetGKsAnnotation] = strings.Join(groupKinds, ",")
+	if len(namespaces) > 0 {
+		annotations[apply.ApplySetAdditionalNamespacesAnnotation] = strings.Join(namespaces, ",")
+	} else {
+		delete(annotations, apply.ApplySetAdditionalNamespacesAnnotation)
+	}
+	parent.SetAnnotations(annotations)
+
+	updated, err := client.Update(ctx, parent, meta_v1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update apply set parent %s: %+v", parent.GetName(), err)
+	}
+	return updated, nil
+}
+
+func resourceKubectlApplySetRead(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	provider := meta.(*KubeProvider)
+	client, err := provider.ToDynamicClient()
+	if err != nil {
+		return err
+	}
+
+	parentManifest := applySetParentManifest(d)
+	restClient := getRestClientFromUnstructured(parentManifest, provider)
+	if restClient.Error != nil {
+		return fmt.Errorf("%v failed to create kubernetes rest client for apply set parent: %+v", parentManifest, restClient.Error)
+	}
+
+	parent, err := restClient.ResourceInterface.Get(ctx, parentManifest.GetName(), meta_v1.GetOptions{})
+	if errors.IsNotFound(err) || errors.IsGone(err) {
+		log.Printf("[WARN] apply set parent (%s) not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%v failed to get apply set parent: %+v", parentManifest, err)
+	}
+
+	_ = d.Set("apply_set_id", applySetID(parentManifest.GetName(), parentManifest.GetNamespace(), parentManifest.GetKind()))
+
+	orphans, err := findApplySetOrphans(ctx, provider, client, parent, expandStringList(d.Get("members").(*schema.Set).List()))
+	if err != nil {
+		return err
+	}
+
+	_ = d.Set("orphaned_objects", orphans)
+	return nil
+}
+
+func resourceKubectlApplySetDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	provider := meta.(*KubeProvider)
+	client, err := provider.ToDynamicClient()
+	if err != nil {
+		return err
+	}
+
+	discoveryClient, err := provider.ToDiscoveryClient()
+	if err != nil {
+		return err
+	}
+
+	parentManifest := applySetParentManifest(d)
+	applySetId := applySetID(parentManifest.GetName(), parentManifest.GetNamespace(), parentManifest.GetKind())
+
+	// the objects are left in the cluster, no longer labelled as part of the apply set
+	for _, id := range expandStringList(d.Get("members").(*schema.Set).List()) {
+		gvr, namespace, name, err := resolveSelfLink(discoveryClient, id)
+		if err != nil {
+			log.Printf("[WARN] unable to remove apply set label from %s: %+v", id, err)
+			continue
+		}
+
+		member := &applySetMember{ID: id, Resource: gvr, Namespace: namespace, Name: name}
+		if err := unlabelApplySetMember(ctx, client, member, applySetId); err != nil {
+			return err
+		}
+	}
+
+	if err := deleteManifest(ctx, provider, parentManifest, false); err != nil {
+		return err
+	}
+
+	d.SetId("")
+	return nil
+}
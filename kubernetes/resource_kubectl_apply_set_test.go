@@ -0,0 +1,255 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/stretchr/testify/assert"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	meta_v1_unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/kubectl/pkg/cmd/apply"
+)
+
+func TestApplySetID(t *testing.T) {
+	// as per the ApplySet specification: base64(sha256(<name>.<namespace>.<kind>.<group>))
+	assert.Equal(t, "applyset-kdySOVBWs584aaOTmku9Ul1xDuN7LXBjs-R96jQcisk-v1", applySetID("my-set", "default", "Secret"))
+	assert.NotEqual(t, applySetID("my-set", "default", "Secret"), applySetID("my-set", "default", "ConfigMap"))
+}
+
+func TestParseSelfLink(t *testing.T) {
+	testCases := []struct {
+		selfLink  string
+		gvr       k8sschema.GroupVersionResource
+		namespace string
+		name      string
+		wantErr   bool
+	}{
+		{
+			selfLink:  "/api/v1/namespaces/default/configmaps/test",
+			gvr:       k8sschema.GroupVersionResource{Version: "v1", Resource: "configmaps"},
+			namespace: "default",
+			name:      "test",
+		},
+		{
+			selfLink: "/api/v1/namespaces/test",
+			gvr:      k8sschema.GroupVersionResource{Version: "v1", Resource: "namespaces"},
+			name:     "test",
+		},
+		{
+			selfLink:  "/apis/apps/v1/namespaces/default/deployments/test",
+			gvr:       k8sschema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			namespace: "default",
+			name:      "test",
+		},
+		{
+			selfLink: "/apis/rbac.authorization.k8s.io/v1/clusterroles/test",
+			gvr:      k8sschema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"},
+			name:     "test",
+		},
+		{selfLink: "/api/v1", wantErr: true},
+		{selfLink: "/apis/apps/v1/namespaces/default/deployments", wantErr: true},
+		{selfLink: "default/test", wantErr: true},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(tcase.selfLink, func(t *testing.T) {
+			gvr, namespace, name, err := parseSelfLink(tcase.selfLink)
+			if tcase.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tcase.gvr, gvr)
+			assert.Equal(t, tcase.namespace, namespace)
+			assert.Equal(t, tcase.name, name)
+		})
+	}
+}
+
+func TestResolveSelfLink(t *testing.T) {
+	discoveryClient := &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: []*meta_v1.APIResourceList{
+		{
+			GroupVersion: "networking.k8s.io/v1",
+			APIResources: []meta_v1.APIResource{
+				{Name: "ingresses", Kind: "Ingress", Namespaced: true},
+				{Name: "ingresses/status", Kind: "Ingress", Namespaced: true},
+				{Name: "networkpolicies", Kind: "NetworkPolicy", Namespaced: true},
+			},
+		},
+	}}}
+
+	networkPolicies := k8sschema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"}
+	for _, id := range []string{
+		"/apis/networking.k8s.io/v1/namespaces/default/networkpolicies/test",
+		// legacy id of a kubectl_manifest whose state has not been upgraded
+		"/apis/networking.k8s.io/v1/namespaces/default/networkpolicys/test",
+	} {
+		gvr, namespace, name, err := resolveSelfLink(discoveryClient, id)
+		assert.NoError(t, err)
+		assert.Equal(t, networkPolicies, gvr)
+		assert.Equal(t, "default", namespace)
+		assert.Equal(t, "test", name)
+	}
+
+	// the legacy name of ingresses is unchanged
+	gvr, _, _, err := resolveSelfLink(discoveryClient, "/apis/networking.k8s.io/v1/namespaces/default/ingresses/test")
+	assert.NoError(t, err)
+	assert.Equal(t, "ingresses", gvr.Resource)
+
+	_, _, _, err = resolveSelfLink(discoveryClient, "/apis/networking.k8s.io/v1/namespaces/default/widgets/test")
+	assert.ErrorContains(t, err, "isn't served by the cluster")
+
+	_, _, _, err = resolveSelfLink(discoveryClient, "/apis/example.com/v1/namespaces/default/widgets/test")
+	assert.Error(t, err)
+}
+
+func TestApplySetMemberLabels(t *testing.T) {
+	configMap := &meta_v1_unstruct.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":      "test",
+			"namespace": "default",
+			"labels":    map[string]interface{}{"app": "test"},
+		},
+	}}
+	client := dynamicfake.NewSimpleDynamicClient(k8sruntime.NewScheme(), configMap)
+	member := &applySetMember{
+		ID:        "/api/v1/namespaces/default/configmaps/test",
+		Resource:  k8sschema.GroupVersionResource{Version: "v1", Resource: "configmaps"},
+		Namespace: "default",
+		Name:      "test",
+	}
+
+	labels := func() map[string]string {
+		live, err := member.resourceClient(client).Get(context.Background(), "test", meta_v1.GetOptions{})
+		assert.NoError(t, err)
+		return live.GetLabels()
+	}
+
+	assert.NoError(t, labelApplySetMember(context.Background(), client, member, "applyset-test-v1"))
+	assert.Equal(t, map[string]string{"app": "test", apply.ApplysetPartOfLabel: "applyset-test-v1"}, labels())
+
+	// labelling a member already in the apply set is a no-op
+	assert.NoError(t, labelApplySetMember(context.Background(), client, member, "applyset-test-v1"))
+
+	// members of another apply set are not moved to this one
+	err := labelApplySetMember(context.Background(), client, member, "applyset-other-v1")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "already part of apply set applyset-test-v1")
+	}
+	assert.NoError(t, unlabelApplySetMember(context.Background(), client, member, "applyset-other-v1"))
+	assert.Equal(t, map[string]string{"app": "test", apply.ApplysetPartOfLabel: "applyset-test-v1"}, labels())
+
+	assert.NoError(t, unlabelApplySetMember(context.Background(), client, member, "applyset-test-v1"))
+	assert.Equal(t, map[string]string{"app": "test"}, labels())
+
+	// members which no longer exist are ignored
+	missing := *member
+	missing.Name = "missing"
+	assert.NoError(t, unlabelApplySetMember(context.Background(), client, &missing, "applyset-test-v1"))
+}
+
+func TestApplySetGroupKinds(t *testing.T) {
+	groupKinds := parseGroupKinds("ConfigMap, Deployment.apps,,ClusterRole.rbac.authorization.k8s.io")
+	assert.Equal(t, []k8sschema.GroupKind{
+		{Kind: "ConfigMap"},
+		{Group: "apps", Kind: "Deployment"},
+		{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"},
+	}, groupKinds)
+
+	var formatted []string
+	for _, gk := range groupKinds {
+		formatted = append(formatted, formatGroupKind(gk))
+	}
+	assert.Equal(t, []string{"ConfigMap", "Deployment.apps", "ClusterRole.rbac.authorization.k8s.io"}, formatted)
+}
+
+func TestAccKubectlApplySet_prune(t *testing.T) {
+	configBoth := `
+resource "kubectl_manifest" "first" {
+	yaml_body = <<YAML
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: apply-set-first
+  namespace: default
+YAML
+}
+
+resource "kubectl_manifest" "second" {
+	yaml_body = <<YAML
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: apply-set-second
+  namespace: default
+YAML
+}
+
+resource "kubectl_apply_set" "test" {
+	name    = "apply-set-prune"
+	members = [kubectl_manifest.first.id, kubectl_manifest.second.id]
+}
+`
+
+	// the second manifest is removed from the apply set members, so is pruned before terraform destroys it
+	configFirst := `
+resource "kubectl_manifest" "first" {
+	yaml_body = <<YAML
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: apply-set-first
+  namespace: default
+YAML
+}
+
+resource "kubectl_apply_set" "test" {
+	name    = "apply-set-prune"
+	members = [kubectl_manifest.first.id]
+}
+`
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckkubectlDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: configBoth,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("kubectl_apply_set.test", "apply_set_id", applySetID("apply-set-prune", "default", "Secret")),
+					resource.TestCheckResourceAttr("kubectl_apply_set.test", "orphaned_objects.#", "0"),
+				),
+			},
+			{
+				Config: configFirst,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("kubectl_apply_set.test", "pruned_objects.#", "1"),
+					resource.TestCheckResourceAttr("kubectl_apply_set.test", "pruned_objects.0", "/api/v1/namespaces/default/configmaps/apply-set-second"),
+					resource.TestCheckResourceAttr("kubectl_apply_set.test", "orphaned_objects.#", "0"),
+				),
+			},
+			{
+				// nothing is left to prune, so the objects pruned by the previous apply are cleared
+				Config: configFirst,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("kubectl_apply_set.test", "pruned_objects.#", "0"),
+				),
+			},
+			{
+				Config:             configFirst,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: false,
+			},
+		},
+	})
+}
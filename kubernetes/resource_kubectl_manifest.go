@@ -8,6 +8,7 @@ import (
 	"github.com/gavinbunney/terraform-provider-kubectl/yaml"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"io"
 	"io/ioutil"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/cli-runtime/pkg/genericiooptions"
@@ -23,17 +24,17 @@ import (
 
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	k8sresource "k8s.io/cli-runtime/pkg/resource"
-	apiregistration "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
 	"k8s.io/kubectl/pkg/cmd/apply"
 	k8sdelete "k8s.io/kubectl/pkg/cmd/delete"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 
-	backoff "github.com/cenkalti/backoff/v4"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
-	apps_v1 "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	meta_v1_unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
 	apiMachineryTypes "k8s.io/apimachinery/pkg/types"
 	yamlWriter "sigs.k8s.io/yaml"
@@ -42,181 +43,68 @@ import (
 	"k8s.io/client-go/dynamic"
 )
 
-const (
-	// https://github.com/kubernetes/kubernetes/blob/master/pkg/controller/deployment/util/deployment_util.go#L93
-	TimedOutReason = "ProgressDeadlineExceeded"
-)
-
 func resourceKubectlManifest() *schema.Resource {
 
 	return &schema.Resource{
 		CreateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-			exponentialBackoffConfig := backoff.NewExponentialBackOff()
-			exponentialBackoffConfig.InitialInterval = 3 * time.Second
-			exponentialBackoffConfig.MaxInterval = 30 * time.Second
-
-			if kubectlApplyRetryCount > 0 {
-				retryConfig := backoff.WithMaxRetries(exponentialBackoffConfig, kubectlApplyRetryCount)
-				retryErr := backoff.Retry(func() error {
-					err := resourceKubectlManifestApply(ctx, d, meta)
-					if err != nil {
-						log.Printf("[ERROR] creating manifest failed: %+v", err)
-					}
-
-					return err
-				}, retryConfig)
-
-				if retryErr != nil {
-					return diag.FromErr(retryErr)
-				}
+			provider, err := getContextProvider(d, meta)
+			if err != nil {
+				return diag.FromErr(err)
+			}
 
-				return nil
-			} else {
-				if applyErr := resourceKubectlManifestApply(ctx, d, meta); applyErr != nil {
-					return diag.FromErr(applyErr)
-				}
+			return retryApply("creating manifest", func() error {
+				return resourceKubectlManifestApply(ctx, d, provider)
+			})
+		},
+		ReadContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+			provider, err := getContextProvider(d, meta)
+			if err != nil {
+				return diag.FromErr(err)
+			}
 
+			if provider.ConfigUnknown() {
+				log.Printf("[WARN] %s provider configuration is unknown until apply, keeping the prior state", d.Id())
 				return nil
 			}
-		},
-		ReadContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-			if err := resourceKubectlManifestRead(ctx, d, meta); err != nil {
+
+			if err := resourceKubectlManifestRead(ctx, d, provider); err != nil {
 				return diag.FromErr(err)
 			}
 
-			return nil
+			return fieldConflictWarnings(d)
 		},
 		DeleteContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-			if err := resourceKubectlManifestDelete(ctx, d, meta); err != nil {
+			provider, err := getContextProvider(d, meta)
+			if err != nil {
+				return diag.FromErr(err)
+			}
+
+			if err := resourceKubectlManifestDelete(ctx, d, provider); err != nil {
 				return diag.FromErr(err)
 			}
 
 			return nil
 		},
 		UpdateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-			exponentialBackoffConfig := backoff.NewExponentialBackOff()
-			exponentialBackoffConfig.InitialInterval = 3 * time.Second
-			exponentialBackoffConfig.MaxInterval = 30 * time.Second
-
-			if kubectlApplyRetryCount > 0 {
-				retryConfig := backoff.WithMaxRetries(exponentialBackoffConfig, kubectlApplyRetryCount)
-				retryErr := backoff.Retry(func() error {
-					err := resourceKubectlManifestApply(ctx, d, meta)
-					if err != nil {
-						log.Printf("[ERROR] updating manifest failed: %+v", err)
-					}
-					return err
-				}, retryConfig)
-
-				if retryErr != nil {
-					return diag.FromErr(retryErr)
-				}
-
-				return nil
-			} else {
-				if applyErr := resourceKubectlManifestApply(ctx, d, meta); applyErr != nil {
-					return diag.FromErr(applyErr)
-				}
-
-				return nil
+			provider, err := getContextProvider(d, meta)
+			if err != nil {
+				return diag.FromErr(err)
 			}
+
+			return retryApply("updating manifest", func() error {
+				return resourceKubectlManifestApply(ctx, d, provider)
+			})
 		},
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(10 * time.Minute),
 		},
 		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				idParts := strings.Split(d.Id(), "//")
-				if len(idParts) != 3 && len(idParts) != 4 {
-					return []*schema.ResourceData{}, fmt.Errorf("expected ID in format apiVersion//kind//name//namespace, received: %s", d.Id())
-				}
-
-				apiVersion := idParts[0]
-				kind := idParts[1]
-				name := idParts[2]
-
-				var yamlString = ""
-				if len(idParts) == 4 {
-					yamlString = fmt.Sprintf(`
-apiVersion: %s
-kind: %s
-metadata:
-  namespace: %s
-  name: %s
-`, apiVersion, kind, idParts[3], name)
-				} else {
-					yamlString = fmt.Sprintf(`
-apiVersion: %s
-kind: %s
-metadata:
-  name: %s
-`, apiVersion, kind, name)
-				}
-
-				manifest, err := yaml.ParseYAML(yamlString)
-				if err != nil {
-					return nil, err
-				}
-				restClient := getRestClientFromUnstructured(manifest, meta.(*KubeProvider))
-
-				if restClient.Error != nil {
-					return []*schema.ResourceData{}, fmt.Errorf("failed to create kubernetes rest client for import of resource: %s %s %s %+v %s %s", apiVersion, kind, name, restClient.Error, yamlString, manifest.Raw)
-				}
-
-				// Get the resource from Kubernetes
-				metaObjLiveRaw, err := restClient.ResourceInterface.Get(ctx, manifest.GetName(), meta_v1.GetOptions{})
-				if err != nil {
-					return []*schema.ResourceData{}, fmt.Errorf("failed to get resource %s %s %s from kubernetes: %+v", apiVersion, kind, name, err)
-				}
-
-				if metaObjLiveRaw.GetUID() == "" {
-					return []*schema.ResourceData{}, fmt.Errorf("failed to parse item and get UUID: %+v", metaObjLiveRaw)
-				}
-
-				metaObjLive := yaml.NewFromUnstructured(metaObjLiveRaw)
-
-				// Capture the UID from the cluster at the current time
-				_ = d.Set("uid", metaObjLive.GetUID())
-				_ = d.Set("live_uid", metaObjLive.GetUID())
-
-				liveManifestFingerprint := getLiveManifestFingerprint(d, metaObjLive, metaObjLive)
-				_ = d.Set("yaml_incluster", liveManifestFingerprint)
-				_ = d.Set("live_manifest_incluster", liveManifestFingerprint)
-
-				// set fields captured normally during creation/updates
-				d.SetId(metaObjLive.GetSelfLink())
-				_ = d.Set("api_version", metaObjLive.GetAPIVersion())
-				_ = d.Set("kind", metaObjLive.GetKind())
-				_ = d.Set("namespace", metaObjLive.GetNamespace())
-				_ = d.Set("name", metaObjLive.GetName())
-				_ = d.Set("force_new", false)
-				_ = d.Set("server_side_apply", false)
-				_ = d.Set("apply_only", false)
-
-				// clear out fields user can't set to try and get parity with yaml_body
-				meta_v1_unstruct.RemoveNestedField(metaObjLive.Raw.Object, "metadata", "creationTimestamp")
-				meta_v1_unstruct.RemoveNestedField(metaObjLive.Raw.Object, "metadata", "resourceVersion")
-				meta_v1_unstruct.RemoveNestedField(metaObjLive.Raw.Object, "metadata", "selfLink")
-				meta_v1_unstruct.RemoveNestedField(metaObjLive.Raw.Object, "metadata", "uid")
-				meta_v1_unstruct.RemoveNestedField(metaObjLive.Raw.Object, "metadata", "annotations", "kubectl.kubernetes.io/last-applied-configuration")
-
-				if len(metaObjLive.Raw.GetAnnotations()) == 0 {
-					meta_v1_unstruct.RemoveNestedField(metaObjLive.Raw.Object, "metadata", "annotations")
-				}
-
-				yamlParsed, err := metaObjLive.AsYAML()
-				if err != nil {
-					return []*schema.ResourceData{}, fmt.Errorf("failed to convert manifest to yaml: %+v", err)
-				}
-
-				_ = d.Set("yaml_body", yamlParsed)
-				_ = d.Set("yaml_body_parsed", yamlParsed)
-
-				return []*schema.ResourceData{d}, nil
-			},
+			StateContext: resourceKubectlManifestImport,
 		},
 		CustomizeDiff: func(context context.Context, d *schema.ResourceDiff, meta interface{}) error {
 
+			customizeDiffFieldManager(d, meta.(*KubeProvider))
+
 			// trigger a recreation if the yaml-body has any pending changes
 			if d.Get("force_new").(bool) {
 				_ = d.ForceNew("yaml_body")
@@ -226,6 +114,8 @@ metadata:
 				log.Printf("[TRACE] yaml_body value interpolated, skipping customized diff")
 				d.SetNewComputed("yaml_body_parsed")
 				d.SetNewComputed("yaml_incluster")
+				d.SetNewComputed("live_manifest_drift")
+				d.SetNewComputed("output_values")
 				return nil
 			}
 
@@ -256,34 +146,68 @@ metadata:
 				obfuscatedYaml.SetNamespace(overrideNamespace.(string))
 			}
 
-			var sensitiveFields []string
-			sensitiveFieldsRaw, hasSensitiveFields := d.GetOk("sensitive_fields")
-			if hasSensitiveFields {
-				sensitiveFields = expandStringList(sensitiveFieldsRaw.([]interface{}))
-			} else if parsedYaml.GetKind() == "Secret" && parsedYaml.GetAPIVersion() == "v1" {
-				sensitiveFields = []string{"data"}
+			sensitiveFields := getSensitiveFields(d, parsedYaml)
+			if err := obfuscateSensitiveFields(obfuscatedYaml, sensitiveFields); err != nil {
+				return err
+			}
+
+			obfuscatedYamlBytes, obfuscatedYamlBytesErr := yamlWriter.Marshal(obfuscatedYaml.Raw.Object)
+			if obfuscatedYamlBytesErr != nil {
+				return fmt.Errorf("failed to serialized obfuscated yaml: %+v", obfuscatedYamlBytesErr)
 			}
 
-			for _, s := range sensitiveFields {
-				fields := strings.Split(s, ".")
-				_, fieldExists, err := meta_v1_unstruct.NestedFieldNoCopy(obfuscatedYaml.Raw.Object, fields...)
-				if fieldExists {
-					err = meta_v1_unstruct.SetNestedField(obfuscatedYaml.Raw.Object, "(sensitive value)", fields...)
+			_ = d.SetNew("yaml_body_parsed", string(obfuscatedYamlBytes))
+
+			// perform a server-side dry-run of the manifest when it is changing, so any errors from the server
+			// are surfaced during plan rather than apply
+			provider := meta.(*KubeProvider)
+			if (provider.DryRunOnPlan || d.Get("dry_run_on_plan").(bool)) && provider.ConfigUnknown() {
+				log.Printf("[TRACE] provider configuration is unknown until apply, skipping dry-run")
+				_ = d.SetNewComputed("yaml_dry_run")
+			} else if (provider.DryRunOnPlan || d.Get("dry_run_on_plan").(bool)) && d.NewValueKnown("kubeconfig_context") && (d.Id() == "" || d.HasChange("yaml_body") || d.HasChange("override_namespace")) {
+				provider, err := getContextProvider(d, meta)
+				if err != nil {
+					return err
+				}
+
+				dryRunYaml, err := yaml.ParseYAML(d.Get("yaml_body").(string))
+				if err != nil {
+					return err
+				}
+
+				if overrideNamespace, ok := d.GetOk("override_namespace"); ok {
+					dryRunYaml.SetNamespace(overrideNamespace.(string))
+				}
+
+				dryRunResult, err := resourceKubectlManifestDryRun(context, d, provider, dryRunYaml)
+				if err != nil {
+					return err
+				}
+
+				if dryRunResult != nil {
+					if err := obfuscateSensitiveFields(dryRunResult, sensitiveFields); err != nil {
+						return err
+					}
+
+					dryRunResultYaml, err := dryRunResult.AsYAML()
 					if err != nil {
-						return fmt.Errorf("failed to obfuscate sensitive field '%s': %+v\nNote: only map values are supported!", s, err)
+						return fmt.Errorf("failed to serialize dry-run yaml: %+v", err)
 					}
+					_ = d.SetNew("yaml_dry_run", dryRunResultYaml)
 				} else {
-					log.Printf("[TRACE] sensitive field %s skipped does not exist", s)
+					_ = d.SetNewComputed("yaml_dry_run")
 				}
 			}
 
-			obfuscatedYamlBytes, obfuscatedYamlBytesErr := yamlWriter.Marshal(obfuscatedYaml.Raw.Object)
-			if obfuscatedYamlBytesErr != nil {
-				return fmt.Errorf("failed to serialized obfuscated yaml: %+v", obfuscatedYamlBytesErr)
+			// the cluster can't be read until apply, such as when it's being replaced, so the values read from it are unknown
+			if d.Id() != "" && provider.ConfigUnknown() {
+				log.Printf("[TRACE] provider configuration is unknown until apply, marking the live values of %s unknown", d.Id())
+				_ = d.SetNewComputed("yaml_incluster")
+				_ = d.SetNewComputed("live_manifest_drift")
+				_ = d.SetNewComputed("output_values")
+				return nil
 			}
 
-			_ = d.SetNew("yaml_body_parsed", string(obfuscatedYamlBytes))
-
 			// Get the UID of the K8s resource as it was when the `resourceKubectlManifestCreate` func completed.
 			createdAtUID := d.Get("uid").(string)
 			// Get the UID of the K8s resource as it currently is in the cluster.
@@ -295,6 +219,7 @@ metadata:
 			if UID != createdAtUID {
 				log.Printf("[TRACE] DETECTED %s vs %s", UID, createdAtUID)
 				_ = d.SetNewComputed("uid")
+				_ = d.SetNewComputed("output_values")
 				return nil
 			}
 
@@ -306,6 +231,16 @@ metadata:
 				_ = d.SetNewComputed("yaml_incluster")
 			}
 
+			// the drifted fields are recalculated on apply, so show them as changing alongside the yaml_incluster
+			if stateYaml != liveStateYaml || d.HasChange("yaml_body") || d.HasChange("ignore_fields") || d.HasChange("sensitive_fields") {
+				_ = d.SetNewComputed("live_manifest_drift")
+			}
+
+			// the outputs are evaluated against the live resource after apply
+			if stateYaml != liveStateYaml || d.HasChange("yaml_body") || d.HasChange("override_namespace") || d.HasChange("outputs") {
+				_ = d.SetNewComputed("output_values")
+			}
+
 			return nil
 		},
 		Schema:        kubectlManifestSchema,
@@ -332,6 +267,12 @@ func resourceKubectlManifestV0() *schema.Resource {
 
 var (
 	kubectlManifestSchema = map[string]*schema.Schema{
+		"kubeconfig_context": {
+			Type:        schema.TypeString,
+			Description: "Context of the provider kubeconfig files to apply the manifest to. Defaults to the provider configuration.",
+			Optional:    true,
+			ForceNew:    true,
+		},
 		"uid": {
 			Type:     schema.TypeString,
 			Computed: true,
@@ -350,6 +291,27 @@ var (
 			Computed:  true,
 			Sensitive: true,
 		},
+		"live_manifest_drift": {
+			Type:        schema.TypeMap,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "Fields which have drifted from the yaml_body, mapped to their current value within kubernetes, with sensitive values obfuscated",
+			Computed:    true,
+		},
+		"drift_detection_mode": driftDetectionModeSchema,
+		"report_conflicts": {
+			Type:        schema.TypeBool,
+			Description: "Default false. Setting to true will warn during plan when fields of the yaml_body have been changed by other field managers when using server-side apply.",
+			Optional:    true,
+			Default:     false,
+		},
+		"field_conflicts": {
+			Type:        schema.TypeMap,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "Fields of the yaml_body which have been changed by other field managers, mapped to the field managers now owning them",
+			Computed:    true,
+		},
+		"outputs":       outputsSchema,
+		"output_values": outputValuesSchema,
 		"api_version": {
 			Type:     schema.TypeString,
 			Computed: true,
@@ -387,7 +349,7 @@ var (
 		},
 		"sensitive_fields": {
 			Type:        schema.TypeList,
-			Elem:        &schema.Schema{Type: schema.TypeString},
+			Elem:        &schema.Schema{Type: schema.TypeString, ValidateFunc: validateFieldSelector},
 			Description: "List of yaml keys with sensitive values. Set these for fields which you want obfuscated in the yaml_body output",
 			Optional:    true,
 		},
@@ -409,6 +371,7 @@ var (
 			Optional:    true,
 			Default:     false,
 		},
+		"field_manager": fieldManagerSchema,
 		"apply_only": {
 			Type:        schema.TypeBool,
 			Description: "Apply only. In other words, it does not delete resource in any case.",
@@ -417,7 +380,7 @@ var (
 		},
 		"ignore_fields": {
 			Type:        schema.TypeList,
-			Elem:        &schema.Schema{Type: schema.TypeString},
+			Elem:        &schema.Schema{Type: schema.TypeString, ValidateFunc: validateFieldSelector},
 			Description: "List of yaml keys to ignore changes to. Set these for fields set by Operators or other processes in kubernetes and as such you don't want to update.",
 			Optional:    true,
 		},
@@ -428,10 +391,22 @@ var (
 		},
 		"wait_for_rollout": {
 			Type:        schema.TypeBool,
-			Description: "Default to true (waiting). Set this flag to wait or not for Deployments and APIService to complete rollout",
+			Description: "Default to true (waiting). Set this flag to wait or not for Deployments, StatefulSets, DaemonSets, ReplicaSets, Jobs and APIService to complete rollout",
 			Optional:    true,
 			Default:     true,
 		},
+		"wait_for": waitForSchema,
+		"dry_run_on_plan": {
+			Type:        schema.TypeBool,
+			Description: "Default false. Set this flag to perform a server-side dry-run apply of the manifest during plan, surfacing any errors from the server.",
+			Optional:    true,
+			Default:     false,
+		},
+		"yaml_dry_run": {
+			Type:        schema.TypeString,
+			Description: "Yaml body as returned from the server-side dry-run during plan, with sensitive values obfuscated",
+			Computed:    true,
+		},
 		"validate_schema": {
 			Type:        schema.TypeBool,
 			Description: "Default to true (validate). Set this flag to not validate the yaml schema before appying.",
@@ -455,33 +430,127 @@ func resourceKubectlManifestApply(ctx context.Context, d *schema.ResourceData, m
 
 	log.Printf("[DEBUG] %v apply kubernetes resource:\n%s", manifest, yamlBody)
 
+	response, client, err := applyManifest(ctx, d, meta.(*KubeProvider), manifest)
+	if err != nil {
+		return err
+	}
+
+	d.SetId(getSelfLink(meta.(*KubeProvider), response))
+	log.Printf("[DEBUG] %v fetched successfully, set id to: %v", manifest, d.Id())
+
+	// Capture the UID at time of update
+	// this allows us to diff these against the actual values
+	// read in by the 'resourceKubectlManifestRead'
+	_ = d.Set("uid", response.GetUID())
+	_ = d.Set("live_uid", response.GetUID())
+
+	liveManifestFingerprint, liveManifestDrift := getLiveManifestFingerprint(d, manifest, response)
+	_ = d.Set("yaml_incluster", liveManifestFingerprint)
+	_ = d.Set("live_manifest_incluster", liveManifestFingerprint)
+	_ = d.Set("live_manifest_drift", liveManifestDrift)
+
+	timeout := d.Timeout(schema.TimeoutCreate)
+
+	if d.Get("wait_for_rollout").(bool) {
+		if check, ok := getRolloutReadinessCheck(manifest); ok {
+			log.Printf("[INFO] %v waiting for %s rollout for %vmin", manifest, check.description, timeout.Minutes())
+			err = resource.RetryContext(ctx, timeout, check.retryFunc(ctx, meta.(*KubeProvider), manifest))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if waitFor := expandWaitFor(d.Get("wait_for").([]interface{})); !waitFor.IsEmpty() {
+		log.Printf("[INFO] %v waiting for conditions for %vmin", manifest, timeout.Minutes())
+		err = resource.RetryContext(ctx, timeout, waitForConditionsFunc(ctx, client, manifest, waitFor))
+		if err != nil {
+			return err
+		}
+	}
+
+	return resourceKubectlManifestReadUsingClient(ctx, d, meta, client, manifest)
+}
+
+// applyManifest applies the manifest to kubernetes, returning the resulting object from the cluster
+// along with the client used to fetch it
+func applyManifest(ctx context.Context, d *schema.ResourceData, provider *KubeProvider, manifest *yaml.Manifest) (*yaml.Manifest, dynamic.ResourceInterface, error) {
+
 	// Create a client to talk to the resource API based on the APIVersion and Kind
 	// defined in the YAML
-	restClient := getRestClientFromUnstructured(manifest, meta.(*KubeProvider))
+	restClient := getRestClientFromUnstructured(manifest, provider)
 	if restClient.Error != nil {
-		return fmt.Errorf("%v failed to create kubernetes rest client for update of resource: %+v", manifest, restClient.Error)
+		return nil, nil, fmt.Errorf("%v failed to create kubernetes rest client for update of resource: %w", manifest, restClient.Error)
+	}
+
+	if provider.NativeApply {
+		if err := migrateFieldManagers(ctx, d, restClient.ResourceInterface, manifest, getFieldManager(d, provider)); err != nil {
+			return nil, nil, err
+		}
+
+		log.Printf("[INFO] %s perform native apply of manifest", manifest)
+
+		response, err := nativeApplyManifest(ctx, d, provider, restClient.ResourceInterface, manifest, false)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%v failed to run apply: %w", manifest, err)
+		}
+
+		return yaml.NewFromUnstructured(response), restClient.ResourceInterface, nil
 	}
 
 	// Update the resource in Kubernetes, using a temp file
-	yamlBody, err = manifest.AsYAML()
+	yamlBody, err := manifest.AsYAML()
 	if err != nil {
-		return fmt.Errorf("%v failed to convert to yaml: %+v", manifest, err)
+		return nil, nil, fmt.Errorf("%v failed to convert to yaml: %+v", manifest, err)
 	}
 
 	tmpfile, _ := ioutil.TempFile("", "*kubectl_manifest.yaml")
 	_, _ = tmpfile.Write([]byte(yamlBody))
 	_ = tmpfile.Close()
 
+	applyOptions := newApplyOptions(d, provider, manifest, yamlBody, tmpfile.Name())
+
+	if err := migrateFieldManagers(ctx, d, restClient.ResourceInterface, manifest, applyOptions.FieldManager); err != nil {
+		_ = os.Remove(tmpfile.Name())
+		return nil, nil, err
+	}
+
+	log.Printf("[INFO] %s perform apply of manifest", manifest)
+
+	err = applyOptions.Run()
+	_ = os.Remove(tmpfile.Name())
+	if err != nil {
+		return nil, nil, fmt.Errorf("%v failed to run apply: %w", manifest, err)
+	}
+
+	log.Printf("[INFO] %v manifest applied, fetch resource from kubernetes", manifest)
+
+	// get the resource from Kubernetes
+	rawResponse, err := restClient.ResourceInterface.Get(ctx, manifest.GetName(), meta_v1.GetOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("%v failed to fetch resource from kubernetes: %w", manifest, err)
+	}
+
+	return yaml.NewFromUnstructured(rawResponse), restClient.ResourceInterface, nil
+}
+
+// resourceDataGetter allows sharing logic between schema.ResourceData and schema.ResourceDiff
+type resourceDataGetter interface {
+	Get(key string) interface{}
+}
+
+// newApplyOptions builds the kubectl apply options for the manifest, reading the yaml from filename
+func newApplyOptions(d resourceDataGetter, provider *KubeProvider, manifest *yaml.Manifest, yamlBody string, filename string) *apply.ApplyOptions {
 	applyOptions := &apply.ApplyOptions{
 		IOStreams: genericiooptions.IOStreams{
 			In:     strings.NewReader(yamlBody),
 			Out:    log.Writer(),
 			ErrOut: log.Writer(),
 		},
-		Builder: k8sresource.NewBuilder(k8sresource.RESTClientGetter(meta.(*KubeProvider))),
+		Builder: k8sresource.NewBuilder(k8sresource.RESTClientGetter(provider)),
 		DeleteOptions: &k8sdelete.DeleteOptions{
 			FilenameOptions: k8sresource.FilenameOptions{
-				Filenames: []string{tmpfile.Name()},
+				Filenames: []string{filename},
 			},
 		},
 		ToPrinter: func(string) (printers.ResourcePrinter, error) {
@@ -501,7 +570,7 @@ func resourceKubectlManifestApply(ctx context.Context, d *schema.ResourceData, m
 
 	if d.Get("server_side_apply").(bool) {
 		applyOptions.ServerSideApply = true
-		applyOptions.FieldManager = "kubectl"
+		applyOptions.FieldManager = getFieldManager(d, provider)
 	}
 
 	if d.Get("force_conflicts").(bool) {
@@ -512,58 +581,123 @@ func resourceKubectlManifestApply(ctx context.Context, d *schema.ResourceData, m
 		applyOptions.Namespace = manifest.GetNamespace()
 	}
 
-	log.Printf("[INFO] %s perform apply of manifest", manifest)
+	return applyOptions
+}
+
+// resourceKubectlManifestDryRun performs a server-side dry-run apply of the manifest, returning the object
+// as it would be persisted by kubernetes. Returns nil if the dry-run could not be performed, such as when the
+// resource type or namespace does not exist in the cluster yet.
+func resourceKubectlManifestDryRun(ctx context.Context, d *schema.ResourceDiff, provider *KubeProvider, manifest *yaml.Manifest) (*yaml.Manifest, error) {
+
+	restClient := getRestClientFromUnstructured(manifest, provider)
+	if restClient.Status == RestClientInvalidTypeError {
+		log.Printf("[WARN] %v resource type is not yet known to the cluster, skipping dry-run: %+v", manifest, restClient.Error)
+		return nil, nil
+	}
+
+	if restClient.Error != nil {
+		log.Printf("[WARN] %v unable to reach kubernetes, skipping dry-run: %+v", manifest, restClient.Error)
+		return nil, nil
+	}
+
+	if provider.NativeApply {
+		log.Printf("[INFO] %v perform native server-side dry-run of manifest", manifest)
+
+		dryRunObject, err := nativeApplyManifest(ctx, d, provider, restClient.ResourceInterface, manifest, true)
+		if errors.IsNotFound(err) {
+			log.Printf("[WARN] %v dependent resource not found, skipping dry-run: %+v", manifest, err)
+			return nil, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%v failed server-side dry-run: %+v", manifest, err)
+		}
+
+		return cleanDryRunManifest(yaml.NewFromUnstructured(dryRunObject)), nil
+	}
+
+	yamlBody, err := manifest.AsYAML()
+	if err != nil {
+		return nil, fmt.Errorf("%v failed to convert to yaml: %+v", manifest, err)
+	}
+
+	tmpfile, _ := ioutil.TempFile("", "*kubectl_manifest.yaml")
+	_, _ = tmpfile.Write([]byte(yamlBody))
+	_ = tmpfile.Close()
+	defer os.Remove(tmpfile.Name())
+
+	var dryRunObject k8sruntime.Object
+	applyOptions := newApplyOptions(d, provider, manifest, yamlBody, tmpfile.Name())
+	applyOptions.DryRunStrategy = cmdutil.DryRunServer
+	applyOptions.ToPrinter = func(string) (printers.ResourcePrinter, error) {
+		return printers.ResourcePrinterFunc(func(obj k8sruntime.Object, _ io.Writer) error {
+			dryRunObject = obj
+			return nil
+		}), nil
+	}
+
+	log.Printf("[INFO] %v perform server-side dry-run of manifest", manifest)
 
 	err = applyOptions.Run()
-	_ = os.Remove(tmpfile.Name())
+	if errors.IsNotFound(err) {
+		log.Printf("[WARN] %v dependent resource not found, skipping dry-run: %+v", manifest, err)
+		return nil, nil
+	}
+
 	if err != nil {
-		return fmt.Errorf("%v failed to run apply: %+v", manifest, err)
+		return nil, fmt.Errorf("%v failed server-side dry-run: %+v", manifest, err)
 	}
 
-	log.Printf("[INFO] %v manifest applied, fetch resource from kubernetes", manifest)
+	if dryRunObject == nil {
+		return nil, nil
+	}
 
-	// get the resource from Kubernetes
-	rawResponse, err := restClient.ResourceInterface.Get(ctx, manifest.GetName(), meta_v1.GetOptions{})
+	unstructuredContent, err := k8sruntime.DefaultUnstructuredConverter.ToUnstructured(dryRunObject)
 	if err != nil {
-		return fmt.Errorf("%v failed to fetch resource from kubernetes: %+v", manifest, err)
+		return nil, fmt.Errorf("%v failed to convert dry-run result: %+v", manifest, err)
 	}
 
-	response := yaml.NewFromUnstructured(rawResponse)
+	return cleanDryRunManifest(yaml.NewFromUnstructured(&meta_v1_unstruct.Unstructured{Object: unstructuredContent})), nil
+}
 
-	d.SetId(response.GetSelfLink())
-	log.Printf("[DEBUG] %v fetched successfully, set id to: %v", manifest, d.Id())
+// cleanDryRunManifest removes the control fields and the last-applied-configuration from the dry-run result
+func cleanDryRunManifest(dryRunManifest *yaml.Manifest) *yaml.Manifest {
+	for _, field := range kubernetesControlFields {
+		meta_v1_unstruct.RemoveNestedField(dryRunManifest.Raw.Object, strings.Split(field, ".")...)
+	}
+	meta_v1_unstruct.RemoveNestedField(dryRunManifest.Raw.Object, "metadata", "annotations", "kubectl.kubernetes.io/last-applied-configuration")
+	meta_v1_unstruct.RemoveNestedField(dryRunManifest.Raw.Object, "metadata", "selfLink")
+	if len(dryRunManifest.Raw.GetAnnotations()) == 0 {
+		meta_v1_unstruct.RemoveNestedField(dryRunManifest.Raw.Object, "metadata", "annotations")
+	}
 
-	// Capture the UID at time of update
-	// this allows us to diff these against the actual values
-	// read in by the 'resourceKubectlManifestRead'
-	_ = d.Set("uid", response.GetUID())
-	_ = d.Set("live_uid", response.GetUID())
+	return dryRunManifest
+}
 
-	liveManifestFingerprint := getLiveManifestFingerprint(d, manifest, response)
-	_ = d.Set("yaml_incluster", liveManifestFingerprint)
-	_ = d.Set("live_manifest_incluster", liveManifestFingerprint)
+// getSensitiveFields returns the configured sensitive fields for the manifest, defaulting to the data and stringData of Secrets
+func getSensitiveFields(d resourceDataGetter, manifest *yaml.Manifest) []string {
+	if sensitiveFieldsRaw := d.Get("sensitive_fields").([]interface{}); len(sensitiveFieldsRaw) > 0 {
+		return expandStringList(sensitiveFieldsRaw)
+	} else if manifest.GetKind() == "Secret" && manifest.GetAPIVersion() == "v1" {
+		return []string{"data", "stringData"}
+	}
+	return nil
+}
 
-	if d.Get("wait_for_rollout").(bool) {
-		timeout := d.Timeout(schema.TimeoutCreate)
+// obfuscateSensitiveFields replaces the values of the sensitive fields in the manifest, including any list
+// elements or map keys matched by wildcards and list selectors
+func obfuscateSensitiveFields(manifest *yaml.Manifest, sensitiveFields []string) error {
+	for _, s := range sensitiveFields {
+		selector, err := flatten.ParseSelector(s)
+		if err != nil {
+			return fmt.Errorf("failed to obfuscate sensitive field '%s': %+v", s, err)
+		}
 
-		if manifest.GetKind() == "Deployment" {
-			log.Printf("[INFO] %v waiting for deployment rollout for %vmin", manifest, timeout.Minutes())
-			err = resource.RetryContext(ctx, timeout,
-				waitForDeploymentReplicasFunc(ctx, meta.(*KubeProvider), manifest.GetNamespace(), manifest.GetName()))
-			if err != nil {
-				return err
-			}
-		} else if manifest.GetKind() == "APIService" && manifest.GetAPIVersion() == "apiregistration.k8s.io/v1" {
-			log.Printf("[INFO] %v waiting for APIService rollout for %vmin", manifest, timeout.Minutes())
-			err = resource.RetryContext(ctx, timeout,
-				waitForAPIServiceAvailableFunc(ctx, meta.(*KubeProvider), manifest.GetName()))
-			if err != nil {
-				return err
-			}
+		if selector.Set(manifest.Raw.Object, "(sensitive value)") == 0 {
+			log.Printf("[TRACE] sensitive field %s skipped does not exist", s)
 		}
 	}
-
-	return resourceKubectlManifestReadUsingClient(ctx, d, meta, restClient.ResourceInterface, manifest)
+	return nil
 }
 
 func resourceKubectlManifestRead(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
@@ -616,11 +750,22 @@ func resourceKubectlManifestReadUsingClient(ctx context.Context, d *schema.Resou
 
 	metaObjLive := yaml.NewFromUnstructured(metaObjLiveRaw)
 
+	// rewrite ids built by previous versions using the legacy resource name of the kind
+	d.SetId(upgradeSelfLink(meta, d.Id(), metaObjLive))
+
 	// Capture the UID from the cluster at the current time
 	_ = d.Set("live_uid", metaObjLive.GetUID())
 
-	liveManifestFingerprint := getLiveManifestFingerprint(d, manifest, metaObjLive)
+	liveManifestFingerprint, liveManifestDrift := getLiveManifestFingerprint(d, manifest, metaObjLive)
 	_ = d.Set("live_manifest_incluster", liveManifestFingerprint)
+	_ = d.Set("live_manifest_drift", liveManifestDrift)
+	_ = d.Set("field_conflicts", getFieldConflicts(d, manifest, metaObjLive))
+
+	outputValues, err := getOutputValues(d.Get("outputs").(map[string]interface{}), metaObjLive, getSensitiveFields(d, manifest))
+	if err != nil {
+		return err
+	}
+	_ = d.Set("output_values", outputValues)
 
 	return nil
 }
@@ -641,7 +786,18 @@ func resourceKubectlManifestDelete(ctx context.Context, d *schema.ResourceData,
 
 	log.Printf("[DEBUG] %v delete kubernetes resource:\n%s", manifest, yamlBody)
 
-	restClient := getRestClientFromUnstructured(manifest, meta.(*KubeProvider))
+	if err := deleteManifest(ctx, meta.(*KubeProvider), manifest, d.Get("wait").(bool)); err != nil {
+		return err
+	}
+
+	// Success remove it from state
+	d.SetId("")
+	return nil
+}
+
+// deleteManifest deletes the manifest from kubernetes, optionally waiting for the deletion to complete
+func deleteManifest(ctx context.Context, provider *KubeProvider, manifest *yaml.Manifest, waitForDelete bool) error {
+	restClient := getRestClientFromUnstructured(manifest, provider)
 	if restClient.Error != nil {
 		return fmt.Errorf("%v failed to create kubernetes rest client for delete of resource: %+v", manifest, restClient.Error)
 	}
@@ -649,11 +805,10 @@ func resourceKubectlManifestDelete(ctx context.Context, d *schema.ResourceData,
 	log.Printf("[INFO] %s perform delete of manifest", manifest)
 
 	propagationPolicy := meta_v1.DeletePropagationBackground
-	waitForDelete := d.Get("wait").(bool)
 	if waitForDelete {
 		propagationPolicy = meta_v1.DeletePropagationForeground
 	}
-	err = restClient.ResourceInterface.Delete(ctx, manifest.GetName(), meta_v1.DeleteOptions{PropagationPolicy: &propagationPolicy})
+	err := restClient.ResourceInterface.Delete(ctx, manifest.GetName(), meta_v1.DeleteOptions{PropagationPolicy: &propagationPolicy})
 	resourceGone := errors.IsGone(err) || errors.IsNotFound(err)
 	if err != nil && !resourceGone {
 		return fmt.Errorf("%v failed to delete kubernetes resource: %+v", manifest, err)
@@ -669,12 +824,10 @@ func resourceKubectlManifestDelete(ctx context.Context, d *schema.ResourceData,
 			}
 			return fmt.Errorf("%v failed to delete kubernetes resource: %+v", manifest, err)
 		}
-		log.Printf("[DEBUG] %v waiting for deletion of the resource:\n%s", manifest, yamlBody)
+		log.Printf("[DEBUG] %v waiting for deletion of the resource", manifest)
 		time.Sleep(time.Second * 10)
 	}
 
-	// Success remove it from state
-	d.SetId("")
 	return nil
 }
 
@@ -690,6 +843,9 @@ type RestClientResult struct {
 	ResourceInterface dynamic.ResourceInterface
 	Error             error
 	Status            RestClientStatus
+	// NamespaceableResourceInterface is the client for the resource across all namespaces
+	NamespaceableResourceInterface dynamic.NamespaceableResourceInterface
+	Namespaced                     bool
 }
 
 func RestClientResultSuccess(resourceInterface dynamic.ResourceInterface) *RestClientResult {
@@ -716,13 +872,39 @@ func RestClientResultFromInvalidTypeErr(err error) *RestClientResult {
 	}
 }
 
+// getSelfLink returns the self link used as the id of the manifest, using the rest mapper to find the resource name of the kind
+func getSelfLink(provider *KubeProvider, manifest *yaml.Manifest) string {
+	mapper, err := provider.ToRESTMapper()
+	if err != nil {
+		log.Printf("[WARN] %v unable to create rest mapper, using legacy self link: %+v", manifest, err)
+		return manifest.GetSelfLink()
+	}
+
+	return manifest.GetResourceSelfLink(mapper)
+}
+
+// upgradeSelfLink rewrites an id built using the legacy resource name of the kind to the self link from getSelfLink.
+// Ids are left unchanged when the provider is not configured or the kind can't be mapped.
+func upgradeSelfLink(meta interface{}, id string, manifest *yaml.Manifest) string {
+	provider, ok := meta.(*KubeProvider)
+	if !ok || provider == nil || id != manifest.GetSelfLink() {
+		return id
+	}
+
+	return getSelfLink(provider, manifest)
+}
+
 func getRestClientFromUnstructured(manifest *yaml.Manifest, provider *KubeProvider) *RestClientResult {
 
 	doGetRestClientFromUnstructured := func(manifest *yaml.Manifest, provider *KubeProvider) *RestClientResult {
 		// Use the k8s Discovery service to find all valid APIs for this cluster
-		discoveryClient, _ := provider.ToDiscoveryClient()
+		discoveryClient, err := provider.ToDiscoveryClient()
+		if err != nil {
+			return RestClientResultFromErr(err)
+		}
+
+		generation := provider.DiscoveryGeneration()
 		var resources []*meta_v1.APIResourceList
-		var err error
 		_, resources, err = discoveryClient.ServerGroupsAndResources()
 
 		// There is a partial failure mode here where not all groups are returned `GroupDiscoveryFailedError`
@@ -735,9 +917,11 @@ func getRestClientFromUnstructured(manifest *yaml.Manifest, provider *KubeProvid
 		// Validate that the APIVersion provided in the YAML is valid for this cluster
 		apiResource, exists := checkAPIResourceIsPresent(resources, *manifest.Raw)
 		if !exists {
-			// api not found, invalidate the cache and try again
+			// api not found, refresh the cache and try again
 			// this handles the case when a CRD is being created by another kubectl_manifest resource run
-			discoveryClient.Invalidate()
+			if err := provider.RefreshDiscovery(generation); err != nil {
+				return RestClientResultFromErr(err)
+			}
 			_, resources, err = discoveryClient.ServerGroupsAndResources()
 
 			if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
@@ -747,7 +931,9 @@ func getRestClientFromUnstructured(manifest *yaml.Manifest, provider *KubeProvid
 			// check for resource again
 			apiResource, exists = checkAPIResourceIsPresent(resources, *manifest.Raw)
 			if !exists {
-				return RestClientResultFromInvalidTypeErr(fmt.Errorf("resource [%s/%s] isn't valid for cluster, check the APIVersion and Kind fields are valid", manifest.Raw.GroupVersionKind().GroupVersion().String(), manifest.GetKind()))
+				gvk := manifest.Raw.GroupVersionKind()
+				noMatch := &meta.NoKindMatchError{GroupKind: gvk.GroupKind(), SearchedVersions: []string{gvk.Version}}
+				return RestClientResultFromInvalidTypeErr(fmt.Errorf("resource [%s/%s] isn't valid for cluster, check the APIVersion and Kind fields are valid: %w", gvk.GroupVersion().String(), manifest.GetKind(), noMatch))
 			}
 		}
 
@@ -758,34 +944,44 @@ func getRestClientFromUnstructured(manifest *yaml.Manifest, provider *KubeProvid
 			resourceStruct.Group = ""
 			resourceStruct.Version = "v1"
 		}
-		client := dynamic.NewForConfigOrDie(&provider.RestConfig).Resource(resourceStruct)
+		dynamicClient, err := provider.ToDynamicClient()
+		if err != nil {
+			return RestClientResultFromErr(err)
+		}
+		client := dynamicClient.Resource(resourceStruct)
 
+		var result *RestClientResult
 		if apiResource.Namespaced {
 			if !manifest.HasNamespace() {
 				manifest.SetNamespace("default")
 			}
-			return RestClientResultSuccess(client.Namespace(manifest.GetNamespace()))
+			result = RestClientResultSuccess(client.Namespace(manifest.GetNamespace()))
+		} else {
+			result = RestClientResultSuccess(client)
 		}
 
-		return RestClientResultSuccess(client)
+		result.NamespaceableResourceInterface = client
+		result.Namespaced = apiResource.Namespaced
+		return result
 	}
 
 	discoveryWithTimeout := func(manifest *yaml.Manifest, provider *KubeProvider) <-chan *RestClientResult {
-		ch := make(chan *RestClientResult)
+		// buffered, so the discovery can complete after timing out
+		ch := make(chan *RestClientResult, 1)
 		go func() {
 			ch <- doGetRestClientFromUnstructured(manifest, provider)
 		}()
 		return ch
 	}
 
-	timeout := time.NewTimer(60 * time.Second)
+	timeout := time.NewTimer(provider.DiscoveryTimeout())
 	defer timeout.Stop()
 	select {
 	case res := <-discoveryWithTimeout(manifest, provider):
 		return res
 	case <-timeout.C:
 		log.Printf("[ERROR] %v timed out fetching resources from discovery client", manifest)
-		return RestClientResultFromErr(fmt.Errorf("%v timed out fetching resources from discovery client", manifest))
+		return RestClientResultFromErr(fmt.Errorf("%v %w", manifest, errDiscoveryTimeout))
 	}
 }
 
@@ -811,96 +1007,71 @@ func checkAPIResourceIsPresent(available []*meta_v1.APIResourceList, resource me
 	return nil, false
 }
 
-// GetDeploymentCondition returns the condition with the provided type.
-// Borrowed from: https://github.com/kubernetes/kubernetes/blob/master/pkg/controller/deployment/util/deployment_util.go#L135
-func GetDeploymentCondition(status apps_v1.DeploymentStatus, condType apps_v1.DeploymentConditionType) *apps_v1.DeploymentCondition {
-	for i := range status.Conditions {
-		c := status.Conditions[i]
-		if c.Type == condType {
-			return &c
+// Takes the result of flatmap.Expand for an array of strings
+// and returns a []*string
+func expandStringList(configured []interface{}) []string {
+	vs := make([]string, 0, len(configured))
+	for _, v := range configured {
+		val, ok := v.(string)
+		if ok && val != "" {
+			vs = append(vs, val)
 		}
 	}
-	return nil
+	return vs
 }
 
-func waitForDeploymentReplicasFunc(ctx context.Context, provider *KubeProvider, ns, name string) resource.RetryFunc {
-	return func() *resource.RetryError {
-
-		// Query the deployment to get a status update.
-		dply, err := provider.MainClientset.AppsV1().Deployments(ns).Get(ctx, name, meta_v1.GetOptions{})
-		if err != nil {
-			return resource.NonRetryableError(err)
-		}
-
-		if dply.Generation <= dply.Status.ObservedGeneration {
-			cond := GetDeploymentCondition(dply.Status, apps_v1.DeploymentProgressing)
-			if cond != nil && cond.Reason == TimedOutReason {
-				err := fmt.Errorf("Deployment exceeded its progress deadline: %v", cond.String())
-				return resource.NonRetryableError(err)
-			}
-
-			if dply.Status.UpdatedReplicas < *dply.Spec.Replicas {
-				return resource.RetryableError(fmt.Errorf("Waiting for rollout to finish: %d out of %d new replicas have been updated...", dply.Status.UpdatedReplicas, dply.Spec.Replicas))
-			}
-
-			if dply.Status.Replicas > dply.Status.UpdatedReplicas {
-				return resource.RetryableError(fmt.Errorf("Waiting for rollout to finish: %d old replicas are pending termination...", dply.Status.Replicas-dply.Status.UpdatedReplicas))
-			}
-
-			if dply.Status.AvailableReplicas < dply.Status.UpdatedReplicas {
-				return resource.RetryableError(fmt.Errorf("Waiting for rollout to finish: %d of %d updated replicas are available...", dply.Status.AvailableReplicas, dply.Status.UpdatedReplicas))
-			}
-		} else if dply.Status.ObservedGeneration == 0 {
-			return resource.RetryableError(fmt.Errorf("Waiting for rollout to start"))
-		}
-		return nil
-	}
+func getLiveManifestFingerprint(d resourceDataGetter, userProvided *yaml.Manifest, liveManifest *yaml.Manifest) (string, map[string]string) {
+	fields, drift := getLiveManifestFields(d, userProvided, liveManifest)
+	return getFingerprint(fields), drift
 }
 
-func waitForAPIServiceAvailableFunc(ctx context.Context, provider *KubeProvider, name string) resource.RetryFunc {
-	return func() *resource.RetryError {
+func getLiveManifestFields(d resourceDataGetter, userProvided *yaml.Manifest, liveManifest *yaml.Manifest) (string, map[string]string) {
+	ignoreFields := getIgnoreFields(d)
 
-		apiService, err := provider.AggregatorClientset.ApiregistrationV1().APIServices().Get(ctx, name, meta_v1.GetOptions{})
+	// only fields owned by the field manager are compared, leaving fields other managers have taken ownership of
+	if d.Get("server_side_apply").(bool) && d.Get("drift_detection_mode").(string) == driftDetectionModeOwned {
+		unowned, err := getUnownedFields(userProvided, liveManifest, getAppliedFieldManager(d))
 		if err != nil {
-			return resource.NonRetryableError(err)
+			log.Printf("[WARN] %v unable to determine field ownership, detecting drift on all fields: %+v", userProvided, err)
 		}
 
-		for i := range apiService.Status.Conditions {
-			if apiService.Status.Conditions[i].Type == apiregistration.Available {
-				return nil
-			}
+		for key := range unowned {
+			log.Printf("[TRACE] %v ignoring drift of %s owned by %s", userProvided, key, strings.Join(unowned[key], ","))
+			ignoreFields = append(ignoreFields, key)
 		}
-
-		return resource.RetryableError(fmt.Errorf("Waiting for APIService %v to be Available", name))
 	}
-}
 
-// Takes the result of flatmap.Expand for an array of strings
-// and returns a []*string
-func expandStringList(configured []interface{}) []string {
-	vs := make([]string, 0, len(configured))
-	for _, v := range configured {
-		val, ok := v.(string)
-		if ok && val != "" {
-			vs = append(vs, val)
-		}
-	}
-	return vs
+	fields, drift := getLiveManifestFields_WithIgnoredFields(ignoreFields, userProvided, liveManifest)
+	return fields, maskSensitiveDrift(drift, userProvided, getSensitiveFields(d, userProvided))
 }
 
-func getLiveManifestFingerprint(d *schema.ResourceData, userProvided *yaml.Manifest, liveManifest *yaml.Manifest) string {
-	fields := getLiveManifestFields(d, userProvided, liveManifest)
-	return getFingerprint(fields)
+func validateFieldSelector(v interface{}, k string) ([]string, []error) {
+	if _, err := flatten.ParseSelector(v.(string)); err != nil {
+		return nil, []error{fmt.Errorf("%s: %+v", k, err)}
+	}
+	return nil, nil
 }
 
-func getLiveManifestFields(d *schema.ResourceData, userProvided *yaml.Manifest, liveManifest *yaml.Manifest) string {
+func getIgnoreFields(d resourceDataGetter) []string {
 	var ignoreFields []string = nil
-	ignoreFieldsRaw, hasIgnoreFields := d.GetOk("ignore_fields")
-	if hasIgnoreFields {
-		ignoreFields = expandStringList(ignoreFieldsRaw.([]interface{}))
+	if ignoreFieldsRaw, ok := d.Get("ignore_fields").([]interface{}); ok && len(ignoreFieldsRaw) > 0 {
+		ignoreFields = expandStringList(ignoreFieldsRaw)
 	}
+	return ignoreFields
+}
 
-	return getLiveManifestFields_WithIgnoredFields(ignoreFields, userProvided, liveManifest)
+// maskSensitiveDrift obfuscates the values of any drifted fields which are, or are nested within, a sensitive field
+func maskSensitiveDrift(drift map[string]string, userProvided *yaml.Manifest, sensitiveFields []string) map[string]string {
+	userPaths := flatten.FlattenPaths(userProvided.Raw.Object)
+	for _, s := range sensitiveFields {
+		selector, err := flatten.ParseSelector(s)
+		for k := range drift {
+			if k == s || strings.HasPrefix(k, s+".") || (err == nil && selector.Matches(userProvided.Raw.Object, userPaths[k])) {
+				drift[k] = "(sensitive value)"
+			}
+		}
+	}
+	return drift
 }
 
 func getFingerprint(s string) string {
@@ -909,7 +1080,10 @@ func getFingerprint(s string) string {
 	return fmt.Sprintf("%x", fingerprint.Sum(nil))
 }
 
-func getLiveManifestFields_WithIgnoredFields(ignoredFields []string, userProvided *yaml.Manifest, liveManifest *yaml.Manifest) string {
+// getLiveManifestFields_WithIgnoredFields returns the user provided fields with their live values, used to
+// fingerprint the resource, along with a map of the fields which have drifted from the user provided value
+// to their live value (blank if the field no longer exists)
+func getLiveManifestFields_WithIgnoredFields(ignoredFields []string, userProvided *yaml.Manifest, liveManifest *yaml.Manifest) (string, map[string]string) {
 
 	flattenedUser := flatten.Flatten(userProvided.Raw.Object)
 	flattenedLive := flatten.Flatten(liveManifest.Raw.Object)
@@ -931,9 +1105,28 @@ func getLiveManifestFields_WithIgnoredFields(ignoredFields []string, userProvide
 		}
 	}
 
+	// match the ignored fields against the path of each key, supporting wildcards, list selectors and quoted keys
+	if len(ignoredFields) > 0 {
+		userPaths := flatten.FlattenPaths(userProvided.Raw.Object)
+		for _, field := range ignoredFields {
+			selector, err := flatten.ParseSelector(field)
+			if err != nil {
+				log.Printf("[WARN] %v unable to parse ignore field: %+v", userProvided, err)
+				continue
+			}
+
+			for k := range flattenedUser {
+				if selector.Matches(userProvided.Raw.Object, userPaths[k]) {
+					delete(flattenedUser, k)
+				}
+			}
+		}
+	}
+
 	// update the user provided flattened string with the live versions of the keys
 	// this implicitly excludes anything that the user didn't provide as it was added by kubernetes runtime (annotations/mutations etc)
 	userKeys := []string{}
+	drift := map[string]string{}
 	for userKey, userValue := range flattenedUser {
 		normalizedUserValue := strings.TrimSpace(userValue)
 
@@ -945,10 +1138,12 @@ func getLiveManifestFields_WithIgnoredFields(ignoredFields []string, userProvide
 			flattenedUser[userKey] = normalizedLiveValue
 			if normalizedUserValue != normalizedLiveValue {
 				log.Printf("[TRACE] yaml drift detected in %s for %s, was: %s now: %s", userProvided.GetSelfLink(), userKey, normalizedUserValue, normalizedLiveValue)
+				drift[userKey] = normalizedLiveValue
 			}
 		} else {
 			if normalizedUserValue != "" {
 				log.Printf("[TRACE] yaml drift detected in %s for %s, was %s now blank", userProvided.GetSelfLink(), userKey, normalizedUserValue)
+				drift[userKey] = ""
 			}
 		}
 	}
@@ -959,7 +1154,7 @@ func getLiveManifestFields_WithIgnoredFields(ignoredFields []string, userProvide
 		returnedValues = append(returnedValues, fmt.Sprintf("%s=%s", k, flattenedUser[k]))
 	}
 
-	return strings.Join(returnedValues, ",")
+	return strings.Join(returnedValues, ","), drift
 }
 
 var kubernetesControlFields = []string{
@@ -0,0 +1,454 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gavinbunney/terraform-provider-kubectl/flatten"
+	"github.com/gavinbunney/terraform-provider-kubectl/yaml"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	meta_v1_unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	apiMachineryTypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/csaupgrade"
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// defaultFieldManager is the server-side apply field manager used when none is configured, matching kubectl
+const defaultFieldManager = "kubectl"
+
+// maxManagedFieldsPatchRetry is the number of attempts to patch managed fields when the object is concurrently modified
+const maxManagedFieldsPatchRetry = 5
+
+var lastAppliedAnnotationFieldPath = fieldpath.NewSet(
+	fieldpath.MakePathOrDie("metadata", "annotations", corev1.LastAppliedConfigAnnotation),
+)
+
+const (
+	// driftDetectionModeAll detects drift on all fields of the yaml_body
+	driftDetectionModeAll = "all"
+	// driftDetectionModeOwned detects drift only on fields owned by the field manager, when using server-side apply
+	driftDetectionModeOwned = "owned"
+)
+
+var driftDetectionModeSchema = &schema.Schema{
+	Type:         schema.TypeString,
+	Description:  "Default to all. Setting to owned will only detect drift on fields owned by the field_manager when using server-side apply.",
+	Optional:     true,
+	Default:      driftDetectionModeAll,
+	ValidateFunc: validation.StringInSlice([]string{driftDetectionModeAll, driftDetectionModeOwned}, false),
+}
+
+var fieldManagerSchema = &schema.Schema{
+	Type:        schema.TypeString,
+	Description: "Field manager name used for server-side apply. Defaults to the provider field_manager.",
+	Optional:    true,
+	Computed:    true,
+}
+
+// getFieldManager returns the server-side apply field manager for the resource, falling back to the provider default
+func getFieldManager(d resourceDataGetter, provider *KubeProvider) string {
+	if fieldManager, ok := d.Get("field_manager").(string); ok && fieldManager != "" {
+		return fieldManager
+	}
+
+	return providerFieldManager(provider)
+}
+
+// getAppliedFieldManager returns the field manager the resource was last applied with
+func getAppliedFieldManager(d resourceDataGetter) string {
+	if fieldManager, ok := d.Get("field_manager").(string); ok && fieldManager != "" {
+		return fieldManager
+	}
+
+	return defaultFieldManager
+}
+
+func providerFieldManager(provider *KubeProvider) string {
+	if provider.FieldManager != "" {
+		return provider.FieldManager
+	}
+
+	return defaultFieldManager
+}
+
+// customizeDiffFieldManager plans the provider default field manager when the resource doesn't set one. Field managers
+// only apply to server-side apply, so resources using client-side apply keep their current value.
+func customizeDiffFieldManager(d *schema.ResourceDiff, provider *KubeProvider) {
+	rawConfig := d.GetRawConfig()
+	if rawConfig.IsNull() || !rawConfig.IsKnown() || !rawConfig.GetAttr("field_manager").IsNull() {
+		return
+	}
+
+	if !d.Get("server_side_apply").(bool) {
+		return
+	}
+
+	// resources created before the field manager was configurable have no value and were applied by the default
+	current := d.Get("field_manager").(string)
+	if current == "" && d.Id() != "" {
+		current = defaultFieldManager
+	}
+
+	if fieldManager := providerFieldManager(provider); current != fieldManager {
+		_ = d.SetNew("field_manager", fieldManager)
+	}
+}
+
+// migrateFieldManagers transfers ownership of the fields on the live object to the new field manager before applying,
+// so the apply does not conflict with the fields previously applied by terraform. The migration covers objects
+// previously applied with client-side apply, and objects previously applied with a different field manager name.
+func migrateFieldManagers(ctx context.Context, d *schema.ResourceData, client dynamic.ResourceInterface, manifest *yaml.Manifest, fieldManager string) error {
+	if d.Id() == "" || !d.Get("server_side_apply").(bool) {
+		return nil
+	}
+
+	oldServerSideApply, _ := d.GetChange("server_side_apply")
+	if !oldServerSideApply.(bool) {
+		log.Printf("[INFO] %v migrating client-side apply fields to field manager %s", manifest, fieldManager)
+		return patchManagedFields(ctx, client, manifest, func(live *meta_v1_unstruct.Unstructured) ([]byte, error) {
+			csaManagers := sets.New[string]()
+			for _, entry := range csaupgrade.FindFieldsOwners(live.GetManagedFields(), meta_v1.ManagedFieldsOperationUpdate, lastAppliedAnnotationFieldPath) {
+				csaManagers.Insert(entry.Manager)
+			}
+			return csaupgrade.UpgradeManagedFieldsPatch(live, csaManagers, fieldManager)
+		})
+	}
+
+	oldFieldManager, _ := d.GetChange("field_manager")
+	previous := oldFieldManager.(string)
+	if previous == "" {
+		previous = defaultFieldManager
+	}
+
+	if previous == fieldManager {
+		return nil
+	}
+
+	log.Printf("[INFO] %v migrating fields from field manager %s to %s", manifest, previous, fieldManager)
+	return patchManagedFields(ctx, client, manifest, func(live *meta_v1_unstruct.Unstructured) ([]byte, error) {
+		managedFields, changed, err := renameFieldManagers(live.GetManagedFields(), sets.New[string](previous), fieldManager)
+		if err != nil || !changed {
+			return nil, err
+		}
+
+		return json.Marshal([]map[string]interface{}{
+			{"op": "test", "path": "/metadata/resourceVersion", "value": live.GetResourceVersion()},
+			{"op": "replace", "path": "/metadata/managedFields", "value": managedFields},
+		})
+	})
+}
+
+// patchManagedFields applies the json patch built from the live object, retrying if the object was modified concurrently
+func patchManagedFields(ctx context.Context, client dynamic.ResourceInterface, manifest *yaml.Manifest, buildPatch func(*meta_v1_unstruct.Unstructured) ([]byte, error)) error {
+	var err error
+	for i := 0; i < maxManagedFieldsPatchRetry; i++ {
+		var live *meta_v1_unstruct.Unstructured
+		var patch []byte
+		live, err = client.Get(ctx, manifest.GetName(), meta_v1.GetOptions{})
+		if errors.IsNotFound(err) || errors.IsGone(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%v failed to fetch resource from kubernetes: %+v", manifest, err)
+		}
+
+		patch, err = buildPatch(live)
+		if err != nil {
+			return fmt.Errorf("%v failed to build managed fields migration: %+v", manifest, err)
+		}
+
+		if patch == nil {
+			return nil
+		}
+
+		_, err = client.Patch(ctx, manifest.GetName(), apiMachineryTypes.JSONPatchType, patch, meta_v1.PatchOptions{})
+		if err == nil {
+			return nil
+		}
+
+		if !errors.IsConflict(err) && !errors.IsInvalid(err) {
+			return fmt.Errorf("%v failed to migrate managed fields: %+v", manifest, err)
+		}
+
+		log.Printf("[DEBUG] %v resource modified during managed fields migration, retrying: %+v", manifest, err)
+	}
+
+	return fmt.Errorf("%v failed to migrate managed fields: %+v", manifest, err)
+}
+
+// renameFieldManagers moves the fields owned by any of the previous apply field managers to the field manager,
+// merging them into any existing entry of the field manager
+func renameFieldManagers(entries []meta_v1.ManagedFieldsEntry, previous sets.Set[string], fieldManager string) ([]meta_v1.ManagedFieldsEntry, bool, error) {
+	result := make([]meta_v1.ManagedFieldsEntry, 0, len(entries))
+	targets := map[string]int{}
+	var migrate []meta_v1.ManagedFieldsEntry
+
+	for _, entry := range entries {
+		if entry.Operation != meta_v1.ManagedFieldsOperationApply || entry.Subresource != "" {
+			result = append(result, entry)
+			continue
+		}
+
+		if entry.Manager == fieldManager {
+			if _, ok := targets[entry.APIVersion]; !ok {
+				targets[entry.APIVersion] = len(result)
+			}
+		} else if previous.Has(entry.Manager) {
+			migrate = append(migrate, entry)
+			continue
+		}
+
+		result = append(result, entry)
+	}
+
+	if len(migrate) == 0 {
+		return entries, false, nil
+	}
+
+	for _, entry := range migrate {
+		targetIndex, ok := targets[entry.APIVersion]
+		if !ok {
+			renamed := *entry.DeepCopy()
+			renamed.Manager = fieldManager
+			targets[entry.APIVersion] = len(result)
+			result = append(result, renamed)
+			continue
+		}
+
+		merged, err := unionManagedFields(result[targetIndex], entry)
+		if err != nil {
+			return nil, false, err
+		}
+		result[targetIndex] = merged
+	}
+
+	return result, true, nil
+}
+
+func unionManagedFields(target meta_v1.ManagedFieldsEntry, source meta_v1.ManagedFieldsEntry) (meta_v1.ManagedFieldsEntry, error) {
+	targetSet, err := decodeManagedFieldsSet(target)
+	if err != nil {
+		return target, err
+	}
+
+	sourceSet, err := decodeManagedFieldsSet(source)
+	if err != nil {
+		return target, err
+	}
+
+	raw, err := targetSet.Union(sourceSet).ToJSON()
+	if err != nil {
+		return target, err
+	}
+
+	merged := *target.DeepCopy()
+	merged.FieldsType = "FieldsV1"
+	merged.FieldsV1 = &meta_v1.FieldsV1{Raw: raw}
+	return merged, nil
+}
+
+func decodeManagedFieldsSet(entry meta_v1.ManagedFieldsEntry) (*fieldpath.Set, error) {
+	set := &fieldpath.Set{}
+	if entry.FieldsV1 == nil {
+		return set, nil
+	}
+	err := set.FromJSON(bytes.NewReader(entry.FieldsV1.Raw))
+	return set, err
+}
+
+// getFieldOwners maps the flattened keys of the live object, as used for drift detection, to the field managers owning them
+func getFieldOwners(live *meta_v1_unstruct.Unstructured) (map[string][]string, error) {
+	owners := map[string][]string{}
+	for _, entry := range live.GetManagedFields() {
+		if entry.Subresource != "" {
+			continue
+		}
+
+		set, err := decodeManagedFieldsSet(entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode managed fields of %s: %+v", entry.Manager, err)
+		}
+
+		// only leaves own the values under them, the existence of a list item or map owns nothing beneath it
+		set.Leaves().Iterate(func(path fieldpath.Path) {
+			key, ok := flattenFieldPath(live.Object, path)
+			if !ok || key == "" {
+				return
+			}
+
+			for _, manager := range owners[key] {
+				if manager == entry.Manager {
+					return
+				}
+			}
+			owners[key] = append(owners[key], entry.Manager)
+		})
+	}
+	return owners, nil
+}
+
+// flattenFieldPath converts a managed fields path into the flattened key of the object, resolving list items by
+// their associative key or value to their index. Returns false if the path does not exist within the object.
+func flattenFieldPath(object interface{}, path fieldpath.Path) (string, bool) {
+	parts := make([]string, 0, len(path))
+	current := object
+
+	for _, pe := range path {
+		switch {
+		case pe.FieldName != nil:
+			m, ok := current.(map[string]interface{})
+			if !ok {
+				return "", false
+			}
+			if current, ok = m[*pe.FieldName]; !ok {
+				return "", false
+			}
+			parts = append(parts, *pe.FieldName)
+
+		case pe.Index != nil:
+			l, ok := current.([]interface{})
+			if !ok || *pe.Index < 0 || *pe.Index >= len(l) {
+				return "", false
+			}
+			current = l[*pe.Index]
+			parts = append(parts, strconv.Itoa(*pe.Index))
+
+		case pe.Key != nil || pe.Value != nil:
+			l, ok := current.([]interface{})
+			if !ok {
+				return "", false
+			}
+
+			index := -1
+			for i, item := range l {
+				if listItemMatches(pe, item) {
+					index = i
+					break
+				}
+			}
+			if index < 0 {
+				return "", false
+			}
+			current = l[index]
+			parts = append(parts, strconv.Itoa(index))
+
+		default:
+			return "", false
+		}
+	}
+
+	return strings.Join(parts, "."), true
+}
+
+func listItemMatches(pe fieldpath.PathElement, item interface{}) bool {
+	if pe.Value != nil {
+		return value.Equals(value.NewValueInterface(item), *pe.Value)
+	}
+
+	m, ok := item.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	for _, field := range *pe.Key {
+		v, ok := m[field.Name]
+		if !ok || !value.Equals(value.NewValueInterface(v), field.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// getKeyOwners returns the field managers owning the flattened key, or any of its parents
+func getKeyOwners(owners map[string][]string, key string) []string {
+	var result []string
+	for prefix := key; prefix != ""; {
+		result = append(result, owners[prefix]...)
+
+		i := strings.LastIndex(prefix, ".")
+		if i < 0 {
+			break
+		}
+		prefix = prefix[:i]
+	}
+	return result
+}
+
+// getUnownedFields returns the user provided keys which are owned by other field managers, but not the field manager,
+// mapped to their owners. Keys without any owner, such as the object name, are not included.
+func getUnownedFields(userProvided *yaml.Manifest, liveManifest *yaml.Manifest, fieldManager string) (map[string][]string, error) {
+	owners, err := getFieldOwners(liveManifest.Raw)
+	if err != nil {
+		return nil, err
+	}
+
+	unowned := map[string][]string{}
+	for key := range flatten.Flatten(userProvided.Raw.Object) {
+		keyOwners := sets.New[string](getKeyOwners(owners, key)...)
+		if keyOwners.Len() > 0 && !keyOwners.Has(fieldManager) {
+			unowned[key] = sets.List(keyOwners)
+		}
+	}
+	return unowned, nil
+}
+
+// getFieldConflicts returns the fields of the yaml_body which have drifted and are now owned by other field managers,
+// mapped to the field managers owning them
+func getFieldConflicts(d *schema.ResourceData, userProvided *yaml.Manifest, liveManifest *yaml.Manifest) map[string]string {
+	conflicts := map[string]string{}
+	if !d.Get("server_side_apply").(bool) || !d.Get("report_conflicts").(bool) {
+		return conflicts
+	}
+
+	unowned, err := getUnownedFields(userProvided, liveManifest, getAppliedFieldManager(d))
+	if err != nil {
+		log.Printf("[WARN] %v unable to determine field ownership: %+v", userProvided, err)
+		return conflicts
+	}
+
+	_, drift := getLiveManifestFields_WithIgnoredFields(getIgnoreFields(d), userProvided, liveManifest)
+	for key := range drift {
+		if owners, ok := unowned[key]; ok {
+			conflicts[key] = strings.Join(owners, ",")
+		}
+	}
+	return conflicts
+}
+
+// fieldConflictWarnings reports each of the field conflicts found during read as a warning
+func fieldConflictWarnings(d *schema.ResourceData) diag.Diagnostics {
+	conflicts, _ := d.Get("field_conflicts").(map[string]interface{})
+
+	keys := make([]string, 0, len(conflicts))
+	for key := range conflicts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var diags diag.Diagnostics
+	for _, key := range keys {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  fmt.Sprintf("Field %s of %s is managed by %s", key, d.Id(), conflicts[key]),
+			Detail: fmt.Sprintf("The field %s has been changed by the field manager %s and is no longer owned by %s. "+
+				"Remove the field from the yaml_body to stop managing it, or set force_conflicts to take back ownership.",
+				key, conflicts[key], getAppliedFieldManager(d)),
+		})
+	}
+	return diags
+}
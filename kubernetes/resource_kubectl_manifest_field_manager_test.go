@@ -0,0 +1,257 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/gavinbunney/terraform-provider-kubectl/yaml"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/stretchr/testify/assert"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+)
+
+func managedFieldsEntry(manager string, operation meta_v1.ManagedFieldsOperationType, fields string) meta_v1.ManagedFieldsEntry {
+	return meta_v1.ManagedFieldsEntry{
+		Manager:    manager,
+		Operation:  operation,
+		APIVersion: "v1",
+		FieldsType: "FieldsV1",
+		FieldsV1:   &meta_v1.FieldsV1{Raw: []byte(fields)},
+	}
+}
+
+func TestRenameFieldManagers(t *testing.T) {
+	testCases := []struct {
+		description     string
+		entries         []meta_v1.ManagedFieldsEntry
+		expected        []meta_v1.ManagedFieldsEntry
+		expectedChanged bool
+	}{
+		{
+			description: "No previous manager",
+			entries: []meta_v1.ManagedFieldsEntry{
+				managedFieldsEntry("terraform", meta_v1.ManagedFieldsOperationApply, `{"f:data":{"f:a":{}}}`),
+				managedFieldsEntry("kube-controller-manager", meta_v1.ManagedFieldsOperationUpdate, `{"f:data":{"f:b":{}}}`),
+			},
+			expected: []meta_v1.ManagedFieldsEntry{
+				managedFieldsEntry("terraform", meta_v1.ManagedFieldsOperationApply, `{"f:data":{"f:a":{}}}`),
+				managedFieldsEntry("kube-controller-manager", meta_v1.ManagedFieldsOperationUpdate, `{"f:data":{"f:b":{}}}`),
+			},
+		},
+		{
+			description: "Previous manager renamed",
+			entries: []meta_v1.ManagedFieldsEntry{
+				managedFieldsEntry("kubectl", meta_v1.ManagedFieldsOperationApply, `{"f:data":{"f:a":{}}}`),
+				managedFieldsEntry("kube-controller-manager", meta_v1.ManagedFieldsOperationUpdate, `{"f:data":{"f:b":{}}}`),
+			},
+			expected: []meta_v1.ManagedFieldsEntry{
+				managedFieldsEntry("kube-controller-manager", meta_v1.ManagedFieldsOperationUpdate, `{"f:data":{"f:b":{}}}`),
+				managedFieldsEntry("terraform", meta_v1.ManagedFieldsOperationApply, `{"f:data":{"f:a":{}}}`),
+			},
+			expectedChanged: true,
+		},
+		{
+			description: "Previous manager merged into existing manager",
+			entries: []meta_v1.ManagedFieldsEntry{
+				managedFieldsEntry("kubectl", meta_v1.ManagedFieldsOperationApply, `{"f:data":{"f:a":{}}}`),
+				managedFieldsEntry("terraform", meta_v1.ManagedFieldsOperationApply, `{"f:data":{"f:c":{}}}`),
+			},
+			expected: []meta_v1.ManagedFieldsEntry{
+				managedFieldsEntry("terraform", meta_v1.ManagedFieldsOperationApply, `{"f:data":{"f:a":{},"f:c":{}}}`),
+			},
+			expectedChanged: true,
+		},
+		{
+			description: "Previous manager updates are left alone",
+			entries: []meta_v1.ManagedFieldsEntry{
+				managedFieldsEntry("kubectl", meta_v1.ManagedFieldsOperationUpdate, `{"f:data":{"f:a":{}}}`),
+			},
+			expected: []meta_v1.ManagedFieldsEntry{
+				managedFieldsEntry("kubectl", meta_v1.ManagedFieldsOperationUpdate, `{"f:data":{"f:a":{}}}`),
+			},
+		},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(tcase.description, func(t *testing.T) {
+			entries, changed, err := renameFieldManagers(tcase.entries, sets.New[string]("kubectl"), "terraform")
+			assert.NoError(t, err)
+			assert.Equal(t, tcase.expectedChanged, changed)
+			assert.Equal(t, tcase.expected, entries)
+		})
+	}
+}
+
+func TestAccKubectlFieldManager_migrate(t *testing.T) {
+	config := func(fieldManager string, value string) string {
+		return `
+resource "kubectl_manifest" "test" {
+	server_side_apply = true
+	field_manager     = "` + fieldManager + `"
+	yaml_body = <<YAML
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: field-manager-migrate
+  namespace: default
+data:
+  key: ` + value + `
+YAML
+}
+`
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckkubectlDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config("kubectl", "first"),
+			},
+			{
+				// changing the value and the manager together would conflict without migrating ownership
+				Config: config("terraform", "second"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("kubectl_manifest.test", "field_manager", "terraform"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccKubectlFieldManager_clientSideApply(t *testing.T) {
+	// the provider field manager only applies to server-side apply, so is not planned for client-side apply
+	config := `
+provider "kubectl" {
+	field_manager = "terraform"
+}
+
+resource "kubectl_manifest" "test" {
+	yaml_body = <<YAML
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: field-manager-client-side-apply
+  namespace: default
+data:
+  key: value
+YAML
+}
+`
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckkubectlDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("kubectl_manifest.test", "field_manager", ""),
+				),
+			},
+			{
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: false,
+			},
+		},
+	})
+}
+
+func TestGetUnownedFields(t *testing.T) {
+	userProvided, err := yaml.ParseYAML(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+  labels:
+    app: test
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: app
+        image: nginx:1.25
+      - name: sidecar
+        image: busybox
+`)
+	assert.NoError(t, err)
+
+	live := userProvided.Raw.DeepCopy()
+	_ = unstructured.SetNestedField(live.Object, int64(5), "spec", "replicas")
+	live.SetManagedFields([]meta_v1.ManagedFieldsEntry{
+		{
+			Manager:    "terraform",
+			Operation:  meta_v1.ManagedFieldsOperationApply,
+			APIVersion: "apps/v1",
+			FieldsType: "FieldsV1",
+			FieldsV1: &meta_v1.FieldsV1{Raw: []byte(`{"f:metadata":{"f:labels":{"f:app":{}}},"f:spec":{"f:template":{"f:spec":{"f:containers":{` +
+				`"k:{\"name\":\"app\"}":{".":{},"f:image":{},"f:name":{}},"k:{\"name\":\"sidecar\"}":{".":{},"f:name":{}}}}}}}`)},
+		},
+		{
+			Manager:    "kube-controller-manager",
+			Operation:  meta_v1.ManagedFieldsOperationUpdate,
+			APIVersion: "apps/v1",
+			FieldsType: "FieldsV1",
+			FieldsV1:   &meta_v1.FieldsV1{Raw: []byte(`{"f:spec":{"f:replicas":{}}}`)},
+		},
+		{
+			Manager:    "hpa",
+			Operation:  meta_v1.ManagedFieldsOperationApply,
+			APIVersion: "apps/v1",
+			FieldsType: "FieldsV1",
+			FieldsV1:   &meta_v1.FieldsV1{Raw: []byte(`{"f:spec":{"f:replicas":{},"f:template":{"f:spec":{"f:containers":{"k:{\"name\":\"sidecar\"}":{"f:image":{}}}}}}}`)},
+		},
+	})
+
+	unowned, err := getUnownedFields(userProvided, yaml.NewFromUnstructured(live), "terraform")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"spec.replicas":                         {"hpa", "kube-controller-manager"},
+		"spec.template.spec.containers.1.image": {"hpa"},
+	}, unowned)
+}
+
+func TestFlattenFieldPath(t *testing.T) {
+	object := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"finalizers": []interface{}{"first", "second"},
+		},
+		"spec": map[string]interface{}{
+			"ports": []interface{}{
+				map[string]interface{}{"port": int64(80), "protocol": "TCP"},
+				map[string]interface{}{"port": int64(443), "protocol": "TCP"},
+			},
+		},
+	}
+
+	testCases := []struct {
+		fields   string
+		expected []string
+	}{
+		{fields: `{"f:metadata":{"f:finalizers":{"v:\"second\"":{}}}}`, expected: []string{"metadata.finalizers.1"}},
+		{fields: `{"f:spec":{"f:ports":{"k:{\"port\":443,\"protocol\":\"TCP\"}":{"f:port":{}}}}}`, expected: []string{"spec.ports.1.port"}},
+		{fields: `{"f:spec":{"f:ports":{"k:{\"port\":8080,\"protocol\":\"TCP\"}":{"f:port":{}}}}}`, expected: nil},
+		{fields: `{"f:spec":{"f:missing":{}}}`, expected: nil},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(tcase.fields, func(t *testing.T) {
+			set, err := decodeManagedFieldsSet(meta_v1.ManagedFieldsEntry{FieldsV1: &meta_v1.FieldsV1{Raw: []byte(tcase.fields)}})
+			assert.NoError(t, err)
+
+			var keys []string
+			set.Iterate(func(path fieldpath.Path) {
+				if key, ok := flattenFieldPath(object, path); ok {
+					keys = append(keys, key)
+				}
+			})
+			assert.Equal(t, tcase.expected, keys)
+		})
+	}
+}
@@ -0,0 +1,355 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gavinbunney/terraform-provider-kubectl/yaml"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	meta_v1_unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/csaupgrade"
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+)
+
+// resourceKubectlManifestImport imports the object identified by the id, which is either:
+//
+//	apiVersion//kind//name//namespace  - e.g. apps/v1//Deployment//nginx//default, omitting the namespace for cluster scoped kinds
+//	self link                          - e.g. /apis/apps/v1/namespaces/default/deployments/nginx, as used for the resource id
+//	kind.group/namespace/name          - e.g. deployment.apps/default/nginx, or deployment.apps/nginx for cluster scoped kinds
+//
+// Any of which can be suffixed by @context to import from a context of the provider kubeconfig files.
+func resourceKubectlManifestImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	// object ids can't contain an @, whereas context names often do, such as kubernetes-admin@kubernetes
+	id, kubeconfigContext, _ := strings.Cut(d.Id(), "@")
+	provider, err := meta.(*KubeProvider).ForContext(kubeconfigContext)
+	if err != nil {
+		return []*schema.ResourceData{}, err
+	}
+	_ = d.Set("kubeconfig_context", kubeconfigContext)
+
+	manifest, err := parseImportID(provider, id)
+	if err != nil {
+		return []*schema.ResourceData{}, err
+	}
+
+	restClient := getRestClientFromUnstructured(manifest, provider)
+	if restClient.Error != nil {
+		return []*schema.ResourceData{}, fmt.Errorf("failed to create kubernetes rest client for import of resource: %s %s %s %+v", manifest.GetAPIVersion(), manifest.GetKind(), manifest.GetName(), restClient.Error)
+	}
+
+	// Get the resource from Kubernetes
+	metaObjLiveRaw, err := restClient.ResourceInterface.Get(ctx, manifest.GetName(), meta_v1.GetOptions{})
+	if err != nil {
+		return []*schema.ResourceData{}, fmt.Errorf("failed to get resource %s %s %s from kubernetes: %+v", manifest.GetAPIVersion(), manifest.GetKind(), manifest.GetName(), err)
+	}
+
+	if metaObjLiveRaw.GetUID() == "" {
+		return []*schema.ResourceData{}, fmt.Errorf("failed to parse item and get UUID: %+v", metaObjLiveRaw)
+	}
+
+	metaObjLive := yaml.NewFromUnstructured(metaObjLiveRaw)
+
+	// Capture the UID from the cluster at the current time
+	_ = d.Set("uid", metaObjLive.GetUID())
+	_ = d.Set("live_uid", metaObjLive.GetUID())
+
+	// set fields captured normally during creation/updates
+	d.SetId(getSelfLink(provider, metaObjLive))
+	_ = d.Set("api_version", metaObjLive.GetAPIVersion())
+	_ = d.Set("kind", metaObjLive.GetKind())
+	_ = d.Set("namespace", metaObjLive.GetNamespace())
+	_ = d.Set("name", metaObjLive.GetName())
+	_ = d.Set("force_new", false)
+	_ = d.Set("server_side_apply", false)
+	_ = d.Set("apply_only", false)
+	_ = d.Set("drift_detection_mode", driftDetectionModeAll)
+	_ = d.Set("report_conflicts", false)
+
+	var imported *yaml.Manifest
+	if provider.ImportMode == importModeMinimal {
+		imported, err = minimalImportedManifest(metaObjLive)
+		if err != nil {
+			return []*schema.ResourceData{}, fmt.Errorf("failed to import minimal manifest of %v: %+v", metaObjLive, err)
+		}
+	} else {
+		imported = cleanImportedManifest(yaml.NewFromUnstructured(metaObjLive.Raw.DeepCopy()))
+	}
+
+	// fingerprint the fields of the imported yaml_body, matching the fingerprint of the following reads
+	liveManifestFingerprint, liveManifestDrift := getLiveManifestFingerprint(d, imported, metaObjLive)
+	_ = d.Set("yaml_incluster", liveManifestFingerprint)
+	_ = d.Set("live_manifest_incluster", liveManifestFingerprint)
+	_ = d.Set("live_manifest_drift", liveManifestDrift)
+
+	yamlParsed, err := imported.AsYAML()
+	if err != nil {
+		return []*schema.ResourceData{}, fmt.Errorf("failed to convert manifest to yaml: %+v", err)
+	}
+
+	_ = d.Set("yaml_body", yamlParsed)
+	_ = d.Set("yaml_body_parsed", yamlParsed)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+const (
+	// importModeDefault imports the live object, removing the fields which can't be set
+	importModeDefault = "default"
+	// importModeMinimal imports only the fields of the live object owned by apply field managers
+	importModeMinimal = "minimal"
+)
+
+// cleanImportedManifest clears out fields user can't set to try and get parity with yaml_body
+func cleanImportedManifest(metaObjLive *yaml.Manifest) *yaml.Manifest {
+	meta_v1_unstruct.RemoveNestedField(metaObjLive.Raw.Object, "metadata", "creationTimestamp")
+	meta_v1_unstruct.RemoveNestedField(metaObjLive.Raw.Object, "metadata", "resourceVersion")
+	meta_v1_unstruct.RemoveNestedField(metaObjLive.Raw.Object, "metadata", "selfLink")
+	meta_v1_unstruct.RemoveNestedField(metaObjLive.Raw.Object, "metadata", "uid")
+	meta_v1_unstruct.RemoveNestedField(metaObjLive.Raw.Object, "metadata", "annotations", "kubectl.kubernetes.io/last-applied-configuration")
+
+	if len(metaObjLive.Raw.GetAnnotations()) == 0 {
+		meta_v1_unstruct.RemoveNestedField(metaObjLive.Raw.Object, "metadata", "annotations")
+	}
+
+	return metaObjLive
+}
+
+// minimalImportedManifest returns the fields of the live object owned by the server-side and client-side apply field
+// managers, removing the server defaulted fields and the fields only managed by controllers, along with the
+// kubernetesControlFields. Objects without any apply field managers, such as those created by `kubectl create` or by
+// controllers, can't be imported minimally as the fields they were created with can't be told apart from the fields
+// defaulted by the server.
+func minimalImportedManifest(live *yaml.Manifest) (*yaml.Manifest, error) {
+	applied, err := getAppliedFieldSet(live.Raw.GetManagedFields())
+	if err != nil {
+		return nil, err
+	}
+
+	if applied == nil {
+		return nil, fmt.Errorf("the object has no apply field managers, as when created by kubectl create or by a controller, so the fields it was created with can't be told apart from those defaulted by the server. Set the provider import_mode to %s to import all of its fields", importModeDefault)
+	}
+
+	object, _ := filterByFieldSet(live.Raw.Object, applied).(map[string]interface{})
+	if object == nil {
+		object = map[string]interface{}{}
+	}
+
+	minimal := yaml.NewFromUnstructured(&meta_v1_unstruct.Unstructured{Object: object})
+	minimal.Raw.SetAPIVersion(live.GetAPIVersion())
+	minimal.Raw.SetKind(live.GetKind())
+	minimal.Raw.SetName(live.GetName())
+	if live.GetNamespace() != "" {
+		minimal.Raw.SetNamespace(live.GetNamespace())
+	}
+
+	for _, field := range kubernetesControlFields {
+		meta_v1_unstruct.RemoveNestedField(minimal.Raw.Object, strings.Split(field, ".")...)
+	}
+
+	return cleanImportedManifest(minimal), nil
+}
+
+// getAppliedFieldSet returns the union of the fields owned by server-side apply managers, and the update managers owning
+// the last-applied-configuration annotation from client-side apply. Returns nil if there are no apply managers.
+func getAppliedFieldSet(entries []meta_v1.ManagedFieldsEntry) (*fieldpath.Set, error) {
+	clientSideAppliers := sets.New[string]()
+	for _, entry := range csaupgrade.FindFieldsOwners(entries, meta_v1.ManagedFieldsOperationUpdate, lastAppliedAnnotationFieldPath) {
+		clientSideAppliers.Insert(entry.Manager)
+	}
+
+	var applied *fieldpath.Set
+	for _, entry := range entries {
+		if entry.Subresource != "" {
+			continue
+		}
+
+		if entry.Operation != meta_v1.ManagedFieldsOperationApply && !(entry.Operation == meta_v1.ManagedFieldsOperationUpdate && clientSideAppliers.Has(entry.Manager)) {
+			continue
+		}
+
+		set, err := decodeManagedFieldsSet(entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode managed fields of %s: %+v", entry.Manager, err)
+		}
+
+		if applied == nil {
+			applied = set
+		} else {
+			applied = applied.Union(set)
+		}
+	}
+
+	return applied, nil
+}
+
+// filterByFieldSet returns the parts of the object within the field set. Fields which are members of the set without any
+// children, such as scalars and atomic maps or lists, are kept whole. Maps and lists left empty are removed.
+func filterByFieldSet(object interface{}, set *fieldpath.Set) interface{} {
+	switch value := object.(type) {
+	case map[string]interface{}:
+		result := map[string]interface{}{}
+		for key, child := range value {
+			pe := fieldpath.PathElement{FieldName: &key}
+			if filtered, ok := filterFieldSetChild(pe, child, set); ok {
+				result[key] = filtered
+			}
+		}
+
+		if len(result) == 0 {
+			return nil
+		}
+		return result
+
+	case []interface{}:
+		result := make([]interface{}, 0, len(value))
+		for i, item := range value {
+			index := i
+			pe, found := fieldpath.PathElement{Index: &index}, false
+
+			// list items are identified by their associative keys or value
+			set.Members.Iterate(func(member fieldpath.PathElement) {
+				if !found && listItemMatches(member, item) {
+					pe, found = member, true
+				}
+			})
+			set.Children.Iterate(func(child fieldpath.PathElement) {
+				if !found && listItemMatches(child, item) {
+					pe, found = child, true
+				}
+			})
+
+			if filtered, ok := filterFieldSetChild(pe, item, set); ok {
+				result = append(result, filtered)
+			}
+		}
+
+		if len(result) == 0 {
+			return nil
+		}
+		return result
+	}
+
+	return object
+}
+
+func filterFieldSetChild(pe fieldpath.PathElement, value interface{}, set *fieldpath.Set) (interface{}, bool) {
+	if children, ok := set.Children.Get(pe); ok && !children.Empty() {
+		filtered := filterByFieldSet(value, children)
+		return filtered, filtered != nil
+	}
+
+	if set.Members.Has(pe) {
+		return value, true
+	}
+
+	return nil, false
+}
+
+// parseImportID parses the import id into a manifest identifying the object to import
+func parseImportID(provider *KubeProvider, id string) (*yaml.Manifest, error) {
+	raw := &meta_v1_unstruct.Unstructured{}
+
+	switch {
+	case strings.Contains(id, "//"):
+		idParts := strings.Split(id, "//")
+		if len(idParts) != 3 && len(idParts) != 4 {
+			return nil, fmt.Errorf("expected ID in format apiVersion//kind//name//namespace, received: %s", id)
+		}
+
+		raw.SetAPIVersion(idParts[0])
+		raw.SetKind(idParts[1])
+		raw.SetName(idParts[2])
+		if len(idParts) == 4 {
+			raw.SetNamespace(idParts[3])
+		}
+	case strings.HasPrefix(id, "/"):
+		gvr, namespace, name, err := parseSelfLink(id)
+		if err != nil {
+			return nil, err
+		}
+
+		mapper, err := provider.ToRESTMapper()
+		if err != nil {
+			return nil, err
+		}
+
+		gvk, err := mapper.KindFor(gvr)
+		if err != nil {
+			discoveryClient, discoveryErr := provider.ToDiscoveryClient()
+			if discoveryErr != nil {
+				return nil, discoveryErr
+			}
+
+			resourceList, discoveryErr := discoveryClient.ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+			if discoveryErr != nil {
+				return nil, fmt.Errorf("unable to determine the kind of %s: %+v", id, err)
+			}
+
+			var found bool
+			if gvk, found = legacyResourceKind(gvr, resourceList.APIResources); !found {
+				return nil, fmt.Errorf("unable to determine the kind of %s: %+v", id, err)
+			}
+		}
+
+		raw.SetGroupVersionKind(gvk)
+		raw.SetName(name)
+		raw.SetNamespace(namespace)
+	default:
+		idParts := strings.Split(id, "/")
+		if len(idParts) != 2 && len(idParts) != 3 {
+			return nil, fmt.Errorf("expected ID in format kind.group/namespace/name, apiVersion//kind//name//namespace or a self link, received: %s", id)
+		}
+
+		mapper, err := provider.ToRESTMapper()
+		if err != nil {
+			return nil, err
+		}
+
+		gvk, err := kindForResourceArg(mapper, idParts[0])
+		if err != nil {
+			return nil, fmt.Errorf("unable to determine the kind of %s: %+v", id, err)
+		}
+
+		raw.SetGroupVersionKind(gvk)
+		raw.SetName(idParts[len(idParts)-1])
+		if len(idParts) == 3 {
+			raw.SetNamespace(idParts[1])
+		}
+	}
+
+	return yaml.NewFromUnstructured(raw), nil
+}
+
+// kindForResourceArg resolves a kubectl style resource argument, such as deployment.apps, deploy or deployments.v1.apps
+func kindForResourceArg(mapper meta.RESTMapper, arg string) (k8sschema.GroupVersionKind, error) {
+	fullySpecified, groupResource := k8sschema.ParseResourceArg(strings.ToLower(arg))
+	if fullySpecified != nil {
+		if gvk, err := mapper.KindFor(*fullySpecified); err == nil {
+			return gvk, nil
+		}
+	}
+
+	return mapper.KindFor(groupResource.WithVersion(""))
+}
+
+// legacyResourceKind finds the kind of self links built before resources were pluralized by the rest mapper, such as
+// networkpolicys, from the resources served for the group version
+func legacyResourceKind(gvr k8sschema.GroupVersionResource, apiResources []meta_v1.APIResource) (k8sschema.GroupVersionKind, bool) {
+	for _, apiResource := range apiResources {
+		if strings.Contains(apiResource.Name, "/") {
+			continue
+		}
+
+		if yaml.LegacyResourceName(apiResource.Kind) == gvr.Resource {
+			return gvr.GroupVersion().WithKind(apiResource.Kind), true
+		}
+	}
+
+	return k8sschema.GroupVersionKind{}, false
+}
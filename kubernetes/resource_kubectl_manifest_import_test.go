@@ -0,0 +1,270 @@
+package kubernetes
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gavinbunney/terraform-provider-kubectl/yaml"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func testImportRESTMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(k8sschema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(k8sschema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	mapper.Add(k8sschema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}, meta.RESTScopeNamespace)
+	mapper.Add(k8sschema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, meta.RESTScopeRoot)
+	return mapper
+}
+
+func TestParseImportID_legacy(t *testing.T) {
+	manifest, err := parseImportID(nil, "apps/v1//Deployment//nginx//default")
+	assert.NoError(t, err)
+	assert.Equal(t, "apps/v1", manifest.GetAPIVersion())
+	assert.Equal(t, "Deployment", manifest.GetKind())
+	assert.Equal(t, "nginx", manifest.GetName())
+	assert.Equal(t, "default", manifest.GetNamespace())
+
+	manifest, err = parseImportID(nil, "rbac.authorization.k8s.io/v1//ClusterRole//admin")
+	assert.NoError(t, err)
+	assert.Equal(t, "ClusterRole", manifest.GetKind())
+	assert.Equal(t, "admin", manifest.GetName())
+	assert.Equal(t, "", manifest.GetNamespace())
+
+	_, err = parseImportID(nil, "apps/v1//Deployment")
+	assert.Error(t, err)
+
+	_, err = parseImportID(nil, "deployment.apps/default/nginx/extra")
+	assert.Error(t, err)
+
+	_, err = parseImportID(nil, "/apis/apps/v1/deployments")
+	assert.Error(t, err)
+}
+
+func TestKindForResourceArg(t *testing.T) {
+	mapper := testImportRESTMapper()
+
+	tests := map[string]k8sschema.GroupVersionKind{
+		"deployment.apps":                        {Group: "apps", Version: "v1", Kind: "Deployment"},
+		"Deployment.apps":                        {Group: "apps", Version: "v1", Kind: "Deployment"},
+		"deployments.v1.apps":                    {Group: "apps", Version: "v1", Kind: "Deployment"},
+		"configmap":                              {Version: "v1", Kind: "ConfigMap"},
+		"clusterroles.rbac.authorization.k8s.io": {Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"},
+	}
+
+	for arg, expected := range tests {
+		t.Run(arg, func(t *testing.T) {
+			gvk, err := kindForResourceArg(mapper, arg)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, gvk)
+		})
+	}
+
+	_, err := kindForResourceArg(mapper, "widget.example.com")
+	assert.Error(t, err)
+}
+
+func TestLegacyResourceKind(t *testing.T) {
+	apiResources := []meta_v1.APIResource{
+		{Name: "ingresses", Kind: "Ingress"},
+		{Name: "ingresses/status", Kind: "Ingress"},
+		{Name: "networkpolicies", Kind: "NetworkPolicy"},
+	}
+
+	gvk, found := legacyResourceKind(k8sschema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicys"}, apiResources)
+	assert.True(t, found)
+	assert.Equal(t, k8sschema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "NetworkPolicy"}, gvk)
+
+	gvk, found = legacyResourceKind(k8sschema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}, apiResources)
+	assert.True(t, found)
+	assert.Equal(t, "Ingress", gvk.Kind)
+
+	_, found = legacyResourceKind(k8sschema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "widgets"}, apiResources)
+	assert.False(t, found)
+}
+
+func TestMinimalImportedManifest(t *testing.T) {
+	live, err := yaml.ParseYAML(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: default
+  uid: 2d3b5c6e-1111-2222-3333-444455556666
+  resourceVersion: "1234"
+  generation: 2
+  creationTimestamp: "2024-01-01T00:00:00Z"
+  annotations:
+    deployment.kubernetes.io/revision: "2"
+  labels:
+    app: nginx
+spec:
+  replicas: 2
+  revisionHistoryLimit: 10
+  selector:
+    matchLabels:
+      app: nginx
+  template:
+    metadata:
+      labels:
+        app: nginx
+    spec:
+      restartPolicy: Always
+      containers:
+      - name: nginx
+        image: nginx:1.25
+        imagePullPolicy: IfNotPresent
+        terminationMessagePath: /dev/termination-log
+        ports:
+        - containerPort: 80
+          protocol: TCP
+status:
+  replicas: 2
+`)
+	assert.NoError(t, err)
+
+	live.Raw.SetManagedFields([]meta_v1.ManagedFieldsEntry{
+		managedFieldsEntry("terraform", meta_v1.ManagedFieldsOperationApply, `{"f:metadata":{"f:labels":{"f:app":{}}},"f:spec":{"f:replicas":{},"f:selector":{},"f:template":{"f:metadata":{"f:labels":{"f:app":{}}},"f:spec":{"f:containers":{"k:{\"name\":\"nginx\"}":{".":{},"f:image":{},"f:name":{},"f:ports":{"k:{\"containerPort\":80,\"protocol\":\"TCP\"}":{".":{},"f:containerPort":{}}}}}}}}}`),
+		managedFieldsEntry("kube-controller-manager", meta_v1.ManagedFieldsOperationUpdate, `{"f:metadata":{"f:annotations":{".":{},"f:deployment.kubernetes.io/revision":{}}}}`),
+		{
+			Manager:     "kube-controller-manager",
+			Operation:   meta_v1.ManagedFieldsOperationUpdate,
+			Subresource: "status",
+			FieldsType:  "FieldsV1",
+			FieldsV1:    &meta_v1.FieldsV1{Raw: []byte(`{"f:status":{"f:replicas":{}}}`)},
+		},
+	})
+
+	minimal, err := minimalImportedManifest(live)
+	assert.NoError(t, err)
+
+	minimalYaml, err := minimal.AsYAML()
+	assert.NoError(t, err)
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: nginx
+  name: nginx
+  namespace: default
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: nginx
+  template:
+    metadata:
+      labels:
+        app: nginx
+    spec:
+      containers:
+      - image: nginx:1.25
+        name: nginx
+        ports:
+        - containerPort: 80
+`, minimalYaml)
+
+	// the live object is unchanged
+	assert.Equal(t, "1234", live.Raw.GetResourceVersion())
+}
+
+func TestMinimalImportedManifest_clientSideApply(t *testing.T) {
+	live, err := yaml.ParseYAML(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+  namespace: default
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: '{"apiVersion":"v1","data":{"a":"b"},"kind":"ConfigMap","metadata":{"name":"test","namespace":"default"}}'
+data:
+  a: b
+  injected: value
+`)
+	assert.NoError(t, err)
+
+	live.Raw.SetManagedFields([]meta_v1.ManagedFieldsEntry{
+		managedFieldsEntry("kubectl-client-side-apply", meta_v1.ManagedFieldsOperationUpdate, `{"f:data":{".":{},"f:a":{}},"f:metadata":{"f:annotations":{".":{},"f:kubectl.kubernetes.io/last-applied-configuration":{}}}}`),
+		managedFieldsEntry("injector", meta_v1.ManagedFieldsOperationUpdate, `{"f:data":{"f:injected":{}}}`),
+	})
+
+	minimal, err := minimalImportedManifest(live)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "test", "namespace": "default"},
+		"data":       map[string]interface{}{"a": "b"},
+	}, minimal.Raw.Object)
+}
+
+func TestMinimalImportedManifest_withoutApplyManagers(t *testing.T) {
+	live, err := yaml.ParseYAML(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+  uid: 2d3b5c6e-1111-2222-3333-444455556666
+data:
+  a: b
+`)
+	assert.NoError(t, err)
+
+	live.Raw.SetManagedFields([]meta_v1.ManagedFieldsEntry{
+		managedFieldsEntry("kubectl-create", meta_v1.ManagedFieldsOperationUpdate, `{"f:data":{".":{},"f:a":{}}}`),
+	})
+
+	// the server defaulted fields can't be told apart from the fields the object was created with
+	_, err = minimalImportedManifest(live)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "no apply field managers")
+	}
+}
+
+func TestAccKubectlManifest_importIDs(t *testing.T) {
+	name := fmt.Sprintf("import-%s", acctest.RandStringFromCharSet(10, acctest.CharSetAlphaNum))
+
+	config := fmt.Sprintf(`
+resource "kubectl_manifest" "test" {
+	yaml_body = <<YAML
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: %s
+  namespace: default
+data:
+  foo: bar
+YAML
+}
+`, name)
+
+	importSteps := func(id string) resource.TestStep {
+		return resource.TestStep{
+			Config:                  config,
+			ResourceName:            "kubectl_manifest.test",
+			ImportState:             true,
+			ImportStateId:           id,
+			ImportStateVerify:       true,
+			ImportStateVerifyIgnore: []string{"yaml_body", "yaml_body_parsed", "yaml_incluster", "live_manifest_incluster"},
+		}
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckkubectlDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+			},
+			importSteps(fmt.Sprintf("v1//ConfigMap//%s//default", name)),
+			importSteps(fmt.Sprintf("/api/v1/namespaces/default/configmaps/%s", name)),
+			importSteps(fmt.Sprintf("configmap/default/%s", name)),
+		},
+	})
+}
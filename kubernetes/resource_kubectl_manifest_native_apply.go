@@ -0,0 +1,182 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/gavinbunney/terraform-provider-kubectl/yaml"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	meta_v1_unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	apiMachineryTypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
+	"k8s.io/apimachinery/pkg/util/mergepatch"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/kubectl/pkg/scheme"
+	"k8s.io/kubectl/pkg/util"
+)
+
+// maxNativePatchRetry is the number of attempts to patch an object which is concurrently modified, matching kubectl apply
+const maxNativePatchRetry = 5
+
+// nativeApplyManifest applies the manifest using the dynamic client rather than kubectl apply, so the manifest is never
+// written to disk and the resulting object is returned without fetching it again. Server-side apply sends the manifest
+// as an apply patch, and client-side apply creates the object or sends the three-way merge patch of kubectl apply.
+func nativeApplyManifest(ctx context.Context, d resourceDataGetter, provider *KubeProvider, client dynamic.ResourceInterface, manifest *yaml.Manifest, dryRun bool) (*meta_v1_unstruct.Unstructured, error) {
+	var dryRunOptions []string
+	if dryRun {
+		dryRunOptions = []string{meta_v1.DryRunAll}
+	}
+
+	fieldValidation := nativeFieldValidation(d)
+
+	if d.Get("server_side_apply").(bool) {
+		return nativeServerSideApply(ctx, client, manifest, getFieldManager(d, provider), d.Get("force_conflicts").(bool), fieldValidation, dryRunOptions)
+	}
+
+	return nativeClientSideApply(ctx, client, manifest, fieldValidation, dryRunOptions)
+}
+
+// nativeFieldValidation returns the field validation of the requests sent by native apply. Unknown and duplicate fields
+// are rejected by the server when validate_schema is set, as kubectl apply rejects them, and ignored otherwise.
+func nativeFieldValidation(d resourceDataGetter) string {
+	if d.Get("validate_schema").(bool) {
+		return meta_v1.FieldValidationStrict
+	}
+	return meta_v1.FieldValidationIgnore
+}
+
+// nativeServerSideApply applies the manifest with the field manager. The fields of previous client-side apply and field
+// managers are migrated beforehand by migrateFieldManagers, only when the field manager changes.
+func nativeServerSideApply(ctx context.Context, client dynamic.ResourceInterface, manifest *yaml.Manifest, fieldManager string, force bool, fieldValidation string, dryRun []string) (*meta_v1_unstruct.Unstructured, error) {
+	data, err := manifest.Raw.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize manifest: %+v", err)
+	}
+
+	options := meta_v1.PatchOptions{
+		DryRun:          dryRun,
+		Force:           &force,
+		FieldManager:    fieldManager,
+		FieldValidation: fieldValidation,
+	}
+
+	result, err := client.Patch(ctx, manifest.GetName(), apiMachineryTypes.ApplyPatchType, data, options)
+	if errors.IsConflict(err) {
+		return nil, fmt.Errorf("%v\nthe fields are managed by other field managers, set force_conflicts to take ownership of them", err)
+	}
+	return result, err
+}
+
+// nativeClientSideApply creates the object with the last-applied-configuration annotation, or patches the live object
+// with the three-way merge of the annotation, the manifest and the live object
+func nativeClientSideApply(ctx context.Context, client dynamic.ResourceInterface, manifest *yaml.Manifest, fieldValidation string, dryRun []string) (*meta_v1_unstruct.Unstructured, error) {
+	object := manifest.Raw.DeepCopy()
+
+	modified, err := util.GetModifiedConfiguration(object, true, meta_v1_unstruct.UnstructuredJSONScheme)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the last-applied-configuration: %+v", err)
+	}
+
+	for i := 0; ; i++ {
+		live, err := client.Get(ctx, manifest.GetName(), meta_v1.GetOptions{})
+		if errors.IsNotFound(err) {
+			if err := util.CreateApplyAnnotation(object, meta_v1_unstruct.UnstructuredJSONScheme); err != nil {
+				return nil, fmt.Errorf("failed to set the last-applied-configuration: %+v", err)
+			}
+
+			// remove nulls, so the created object matches the object patched by a later apply of the same manifest
+			pruneNulls(object.Object)
+
+			log.Printf("[DEBUG] %v creating resource", manifest)
+			return client.Create(ctx, object, meta_v1.CreateOptions{DryRun: dryRun, FieldValidation: fieldValidation})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch resource from kubernetes: %+v", err)
+		}
+
+		patchType, patch, err := threeWayMergePatch(live, modified)
+		if err != nil {
+			return nil, err
+		}
+
+		if string(patch) == "{}" {
+			log.Printf("[DEBUG] %v resource unchanged", manifest)
+			return live, nil
+		}
+
+		log.Printf("[DEBUG] %v patching resource with %s", manifest, patchType)
+		result, err := client.Patch(ctx, manifest.GetName(), patchType, patch, meta_v1.PatchOptions{DryRun: dryRun, FieldValidation: fieldValidation})
+		if errors.IsConflict(err) && i < maxNativePatchRetry {
+			log.Printf("[DEBUG] %v resource modified during apply, retrying: %+v", manifest, err)
+			continue
+		}
+
+		return result, err
+	}
+}
+
+// threeWayMergePatch builds the patch from the last-applied-configuration of the live object to the modified
+// configuration, using a strategic merge patch for built-in kinds and a json merge patch for other kinds
+func threeWayMergePatch(live *meta_v1_unstruct.Unstructured, modified []byte) (apiMachineryTypes.PatchType, []byte, error) {
+	current, err := live.MarshalJSON()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to serialize live resource: %+v", err)
+	}
+
+	original, err := util.GetOriginalConfiguration(live)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read the last-applied-configuration: %+v", err)
+	}
+
+	versionedObject, err := scheme.Scheme.New(live.GroupVersionKind())
+	if err == nil {
+		lookupPatchMeta, err := strategicpatch.NewPatchMetaFromStruct(versionedObject)
+		if err != nil {
+			return "", nil, err
+		}
+
+		patch, err := strategicpatch.CreateThreeWayMergePatch(original, modified, current, lookupPatchMeta, true)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to build strategic merge patch: %+v", err)
+		}
+		return apiMachineryTypes.StrategicMergePatchType, patch, nil
+	}
+
+	if !k8sruntime.IsNotRegisteredError(err) {
+		return "", nil, err
+	}
+
+	preconditions := []mergepatch.PreconditionFunc{
+		mergepatch.RequireKeyUnchanged("apiVersion"),
+		mergepatch.RequireKeyUnchanged("kind"),
+		mergepatch.RequireMetadataKeyUnchanged("name"),
+	}
+	patch, err := jsonmergepatch.CreateThreeWayJSONMergePatch(original, modified, current, preconditions...)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to build merge patch: %+v", err)
+	}
+	return apiMachineryTypes.MergePatchType, patch, nil
+}
+
+// pruneNulls removes the null values of the object, as kubectl apply does when creating objects
+func pruneNulls(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if item == nil {
+				delete(v, key)
+			} else {
+				pruneNulls(item)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			pruneNulls(item)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/gavinbunney/terraform-provider-kubectl/yaml"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	meta_v1_unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	apiMachineryTypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/kubectl/pkg/util"
+)
+
+func TestThreeWayMergePatch_builtin(t *testing.T) {
+	applied, err := yaml.ParseYAML(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+  namespace: default
+data:
+  removed: a
+  kept: b
+`)
+	require.NoError(t, err)
+	live := applied.Raw.DeepCopy()
+	require.NoError(t, util.CreateApplyAnnotation(live, meta_v1_unstruct.UnstructuredJSONScheme))
+	live.Object["data"].(map[string]interface{})["external"] = "c"
+
+	desired, err := yaml.ParseYAML(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+  namespace: default
+data:
+  kept: changed
+`)
+	require.NoError(t, err)
+	modified, err := util.GetModifiedConfiguration(desired.Raw, true, meta_v1_unstruct.UnstructuredJSONScheme)
+	require.NoError(t, err)
+
+	patchType, patch, err := threeWayMergePatch(live, modified)
+	require.NoError(t, err)
+	assert.Equal(t, apiMachineryTypes.StrategicMergePatchType, patchType)
+
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal(patch, &result))
+	// fields removed from the manifest are deleted, while fields set by others are kept
+	assert.Equal(t, map[string]interface{}{"kept": "changed", "removed": nil}, result["data"])
+	assert.Contains(t, result["metadata"].(map[string]interface{})["annotations"], corev1.LastAppliedConfigAnnotation)
+}
+
+func TestThreeWayMergePatch_unregistered(t *testing.T) {
+	live := &meta_v1_unstruct.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"metadata":   map[string]interface{}{"name": "test"},
+		"spec":       map[string]interface{}{"size": "small"},
+	}}
+
+	modified, err := util.GetModifiedConfiguration(live, true, meta_v1_unstruct.UnstructuredJSONScheme)
+	require.NoError(t, err)
+
+	// unchanged objects produce an empty patch
+	require.NoError(t, util.CreateApplyAnnotation(live, meta_v1_unstruct.UnstructuredJSONScheme))
+	patchType, patch, err := threeWayMergePatch(live, modified)
+	require.NoError(t, err)
+	assert.Equal(t, apiMachineryTypes.MergePatchType, patchType)
+	assert.Equal(t, "{}", string(patch))
+
+	renamed := live.DeepCopy()
+	renamed.SetName("other")
+	renamedConfiguration, err := util.GetModifiedConfiguration(renamed, true, meta_v1_unstruct.UnstructuredJSONScheme)
+	require.NoError(t, err)
+	_, _, err = threeWayMergePatch(live, renamedConfiguration)
+	assert.ErrorContains(t, err, "precondition failed")
+}
+
+func TestNativeClientSideApply(t *testing.T) {
+	gvr := k8sschema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(k8sruntime.NewScheme(), map[k8sschema.GroupVersionResource]string{gvr: "WidgetList"}).
+		Resource(gvr).Namespace("default")
+
+	manifest, err := yaml.ParseYAML(`
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: test
+  namespace: default
+spec:
+  size: small
+  colour: null
+`)
+	require.NoError(t, err)
+
+	created, err := nativeClientSideApply(context.Background(), client, manifest, meta_v1.FieldValidationStrict, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"size": "small"}, created.Object["spec"])
+	assert.Contains(t, created.GetAnnotations(), corev1.LastAppliedConfigAnnotation)
+
+	manifest.Raw.Object["spec"] = map[string]interface{}{"size": "large"}
+	updated, err := nativeClientSideApply(context.Background(), client, manifest, meta_v1.FieldValidationStrict, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"size": "large"}, updated.Object["spec"])
+
+	live, err := client.Get(context.Background(), "test", meta_v1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, updated.Object["spec"], live.Object["spec"])
+}
+
+// recordingResourceClient records the options of the requests sent to the fake dynamic client, which doesn't support
+// apply patches, so they return the object sent
+type recordingResourceClient struct {
+	dynamic.ResourceInterface
+	createOptions []meta_v1.CreateOptions
+	patchOptions  []meta_v1.PatchOptions
+	patchTypes    []apiMachineryTypes.PatchType
+}
+
+func (c *recordingResourceClient) Create(ctx context.Context, obj *meta_v1_unstruct.Unstructured, options meta_v1.CreateOptions, subresources ...string) (*meta_v1_unstruct.Unstructured, error) {
+	c.createOptions = append(c.createOptions, options)
+	return c.ResourceInterface.Create(ctx, obj, options, subresources...)
+}
+
+func (c *recordingResourceClient) Patch(ctx context.Context, name string, pt apiMachineryTypes.PatchType, data []byte, options meta_v1.PatchOptions, subresources ...string) (*meta_v1_unstruct.Unstructured, error) {
+	c.patchOptions = append(c.patchOptions, options)
+	c.patchTypes = append(c.patchTypes, pt)
+	if pt == apiMachineryTypes.ApplyPatchType {
+		applied := &meta_v1_unstruct.Unstructured{}
+		return applied, applied.UnmarshalJSON(data)
+	}
+	return c.ResourceInterface.Patch(ctx, name, pt, data, options, subresources...)
+}
+
+func TestNativeApplyManifest_fieldValidation(t *testing.T) {
+	gvr := k8sschema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+
+	for _, c := range []struct {
+		validateSchema  bool
+		fieldValidation string
+	}{
+		{true, meta_v1.FieldValidationStrict},
+		{false, meta_v1.FieldValidationIgnore},
+	} {
+		t.Run(fmt.Sprintf("validate_schema=%t", c.validateSchema), func(t *testing.T) {
+			client := &recordingResourceClient{ResourceInterface: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(k8sruntime.NewScheme(), map[k8sschema.GroupVersionResource]string{gvr: "WidgetList"}).
+				Resource(gvr).Namespace("default")}
+
+			manifest, err := yaml.ParseYAML(`
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: test
+  namespace: default
+spec:
+  size: small
+`)
+			require.NoError(t, err)
+
+			d := testResourceData{"server_side_apply": false, "force_conflicts": false, "field_manager": "", "validate_schema": c.validateSchema}
+
+			// client-side apply creates, and then patches the object
+			_, err = nativeApplyManifest(context.Background(), d, &KubeProvider{}, client, manifest, false)
+			require.NoError(t, err)
+			manifest.Raw.Object["spec"] = map[string]interface{}{"size": "large"}
+			_, err = nativeApplyManifest(context.Background(), d, &KubeProvider{}, client, manifest, false)
+			require.NoError(t, err)
+
+			// server-side apply sends an apply patch
+			d["server_side_apply"] = true
+			_, err = nativeApplyManifest(context.Background(), d, &KubeProvider{}, client, manifest, false)
+			require.NoError(t, err)
+
+			require.Len(t, client.createOptions, 1)
+			assert.Equal(t, c.fieldValidation, client.createOptions[0].FieldValidation)
+			require.Len(t, client.patchOptions, 2)
+			for _, options := range client.patchOptions {
+				assert.Equal(t, c.fieldValidation, options.FieldValidation)
+			}
+		})
+	}
+}
+
+func TestNativeServerSideApply_singleRequest(t *testing.T) {
+	gvr := k8sschema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+	client := &recordingResourceClient{ResourceInterface: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(k8sruntime.NewScheme(), map[k8sschema.GroupVersionResource]string{gvr: "WidgetList"}).
+		Resource(gvr).Namespace("default")}
+
+	manifest, err := yaml.ParseYAML(`
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: test
+  namespace: default
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: '{"apiVersion":"example.com/v1","kind":"Widget"}'
+spec:
+  size: small
+`)
+	require.NoError(t, err)
+
+	// the fields of previous managers are migrated before applying, so each apply only sends the apply patch
+	_, err = nativeServerSideApply(context.Background(), client, manifest, defaultFieldManager, false, meta_v1.FieldValidationStrict, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []apiMachineryTypes.PatchType{apiMachineryTypes.ApplyPatchType}, client.patchTypes)
+	assert.Equal(t, defaultFieldManager, client.patchOptions[0].FieldManager)
+}
+
+func TestAccKubectlManifest_nativeApply(t *testing.T) {
+	t.Setenv("KUBECTL_PROVIDER_NATIVE_APPLY", "true")
+
+	config := func(serverSideApply bool, value string) string {
+		return fmt.Sprintf(`
+resource "kubectl_manifest" "test" {
+  server_side_apply = %t
+  yaml_body = <<YAML
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: native-apply
+  namespace: default
+data:
+  value: %s
+YAML
+}
+`, serverSideApply, value)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckkubectlDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config(false, "created"),
+				Check:  resource.TestCheckResourceAttrSet("kubectl_manifest.test", "uid"),
+			},
+			{
+				Config: config(false, "updated"),
+				Check:  resource.TestCheckResourceAttr("kubectl_manifest.test", "live_manifest_drift.%", "0"),
+			},
+			{
+				Config: config(true, "server-side"),
+				Check:  resource.TestCheckResourceAttr("kubectl_manifest.test", "live_manifest_drift.%", "0"),
+			},
+		},
+	})
+}
+
+func TestAccKubectlManifest_nativeApplyValidateSchema(t *testing.T) {
+	t.Setenv("KUBECTL_PROVIDER_NATIVE_APPLY", "true")
+
+	config := func(validateSchema bool) string {
+		return fmt.Sprintf(`
+resource "kubectl_manifest" "test" {
+  validate_schema = %t
+  # the unknown field is dropped by the server, and would otherwise be reported as drift
+  ignore_fields   = ["unknownField"]
+  yaml_body = <<YAML
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: native-apply-validate
+  namespace: default
+unknownField: value
+data:
+  value: test
+YAML
+}
+`, validateSchema)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckkubectlDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config:      config(true),
+				ExpectError: regexp.MustCompile(`unknown field "unknownField"`),
+			},
+			{
+				Config: config(false),
+				Check:  resource.TestCheckResourceAttrSet("kubectl_manifest.test", "uid"),
+			},
+		},
+	})
+}
@@ -0,0 +1,82 @@
+package kubernetes
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/gavinbunney/terraform-provider-kubectl/yaml"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"k8s.io/client-go/util/jsonpath"
+	"k8s.io/kubectl/pkg/cmd/get"
+)
+
+var outputsSchema = &schema.Schema{
+	Type:         schema.TypeMap,
+	Elem:         &schema.Schema{Type: schema.TypeString},
+	Description:  "Map of output names to JSONPath expressions, such as `{.status.loadBalancer.ingress[0].hostname}`, evaluated against the live resource after apply.",
+	Optional:     true,
+	ValidateFunc: validateOutputs,
+}
+
+var outputValuesSchema = &schema.Schema{
+	Type:        schema.TypeMap,
+	Elem:        &schema.Schema{Type: schema.TypeString},
+	Description: "Values of the outputs evaluated against the live resource, with sensitive values obfuscated",
+	Computed:    true,
+}
+
+func validateOutputs(v interface{}, k string) ([]string, []error) {
+	var errs []error
+	for name, expr := range v.(map[string]interface{}) {
+		if _, err := parseOutputJSONPath(name, expr.(string)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %+v", k, err))
+		}
+	}
+	return nil, errs
+}
+
+// parseOutputJSONPath parses the JSONPath expression, accepting expressions without the surrounding braces as kubectl does
+func parseOutputJSONPath(name string, expr string) (*jsonpath.JSONPath, error) {
+	relaxed, err := get.RelaxedJSONPathExpression(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output %s: %+v", name, err)
+	}
+
+	parser := jsonpath.New(name)
+	// status fields may not be populated yet, so missing fields evaluate to an empty value
+	parser.AllowMissingKeys(true)
+	if err := parser.Parse(relaxed); err != nil {
+		return nil, fmt.Errorf("invalid output %s: %+v", name, err)
+	}
+
+	return parser, nil
+}
+
+// getOutputValues evaluates the outputs against the live manifest, with the sensitive fields obfuscated
+func getOutputValues(outputs map[string]interface{}, liveManifest *yaml.Manifest, sensitiveFields []string) (map[string]string, error) {
+	if len(outputs) == 0 {
+		return nil, nil
+	}
+
+	obfuscated := yaml.NewFromUnstructured(liveManifest.Raw.DeepCopy())
+	if err := obfuscateSensitiveFields(obfuscated, sensitiveFields); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(outputs))
+	for name, expr := range outputs {
+		parser, err := parseOutputJSONPath(name, expr.(string))
+		if err != nil {
+			return nil, err
+		}
+
+		buf := &bytes.Buffer{}
+		if err := parser.Execute(buf, obfuscated.Raw.Object); err != nil {
+			return nil, fmt.Errorf("%v failed to evaluate output %s: %+v", liveManifest, name, err)
+		}
+		values[name] = buf.String()
+	}
+
+	return values, nil
+}
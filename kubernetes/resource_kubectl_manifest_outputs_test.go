@@ -0,0 +1,102 @@
+package kubernetes
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/gavinbunney/terraform-provider-kubectl/yaml"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetOutputValues(t *testing.T) {
+	live, err := yaml.ParseYAML(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: test
+  namespace: default
+spec:
+  ports:
+  - name: http
+    port: 80
+  - name: https
+    port: 443
+status:
+  loadBalancer:
+    ingress:
+    - hostname: lb.example.com
+`)
+	assert.NoError(t, err)
+
+	values, err := getOutputValues(map[string]interface{}{
+		"hostname":   "{.status.loadBalancer.ingress[0].hostname}",
+		"relaxed":    ".metadata.name",
+		"https_port": `{.spec.ports[?(@.name=="https")].port}`,
+		"ports":      "{.spec.ports[*].port}",
+		"missing":    "{.status.loadBalancer.ingress[0].ip}",
+		"ingress":    "{.status.loadBalancer}",
+	}, live, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"hostname":   "lb.example.com",
+		"relaxed":    "test",
+		"https_port": "443",
+		"ports":      "80 443",
+		"missing":    "",
+		"ingress":    `{"ingress":[{"hostname":"lb.example.com"}]}`,
+	}, values)
+
+	values, err = getOutputValues(map[string]interface{}{"ports": "{.spec.ports}"}, live, []string{"spec.ports"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"ports": "(sensitive value)"}, values)
+
+	values, err = getOutputValues(nil, live, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, values)
+}
+
+func TestValidateOutputs(t *testing.T) {
+	_, errs := validateOutputs(map[string]interface{}{"valid": "{.metadata.name}"}, "outputs")
+	assert.Empty(t, errs)
+
+	_, errs = validateOutputs(map[string]interface{}{"invalid": "{.metadata.name"}, "outputs")
+	assert.Len(t, errs, 1)
+}
+
+func TestAccKubectlOutputs_configMap(t *testing.T) {
+	config := `
+resource "kubectl_manifest" "test" {
+	outputs = {
+		key       = "{.data.key}"
+		namespace = ".metadata.namespace"
+		uid       = "{.metadata.uid}"
+	}
+	yaml_body = <<YAML
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: outputs-config-map
+  namespace: default
+data:
+  key: value
+YAML
+}
+`
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckkubectlDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("kubectl_manifest.test", "output_values.key", "value"),
+					resource.TestCheckResourceAttr("kubectl_manifest.test", "output_values.namespace", "default"),
+					resource.TestMatchResourceAttr("kubectl_manifest.test", "output_values.uid", regexp.MustCompile(`^[0-9a-f-]{36}$`)),
+				),
+			},
+		},
+	})
+}
@@ -0,0 +1,127 @@
+package kubernetes
+
+import (
+	goerrors "errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	backoff "github.com/cenkalti/backoff/v4"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+)
+
+// retryApply runs the apply function, retrying errors which may succeed later with an exponential backoff up to the
+// configured apply_retry_count. Errors which can never succeed, such as invalid manifests or forbidden requests, fail
+// immediately. When retried, the error reports the failure of every attempt.
+func retryApply(description string, applyFunc func() error) diag.Diagnostics {
+	if kubectlApplyRetryCount == 0 {
+		if applyErr := applyFunc(); applyErr != nil {
+			return diag.FromErr(applyErr)
+		}
+
+		return nil
+	}
+
+	exponentialBackoffConfig := backoff.NewExponentialBackOff()
+	exponentialBackoffConfig.InitialInterval = kubectlApplyRetryInitialInterval
+	exponentialBackoffConfig.MaxInterval = kubectlApplyRetryMaxInterval
+	retryConfig := backoff.WithMaxRetries(exponentialBackoffConfig, kubectlApplyRetryCount)
+
+	var attempts []error
+	retryErr := backoff.Retry(func() error {
+		err := applyFunc()
+		if err == nil {
+			return nil
+		}
+
+		attempts = append(attempts, err)
+		if !isRetryableApplyError(err) {
+			log.Printf("[ERROR] %s failed, not retrying: %+v", description, err)
+			return backoff.Permanent(err)
+		}
+
+		log.Printf("[ERROR] %s failed: %+v", description, err)
+		return err
+	}, retryConfig)
+
+	if retryErr == nil {
+		return nil
+	}
+
+	return applyAttemptsDiagnostics(description, attempts)
+}
+
+// applyAttemptsDiagnostics reports the error of the last attempt, listing the errors of every attempt when retried
+func applyAttemptsDiagnostics(description string, attempts []error) diag.Diagnostics {
+	lastErr := attempts[len(attempts)-1]
+	if len(attempts) == 1 {
+		return diag.FromErr(lastErr)
+	}
+
+	var detail strings.Builder
+	_, _ = fmt.Fprintf(&detail, "%s failed after %d attempts:", description, len(attempts))
+	for i, err := range attempts {
+		_, _ = fmt.Fprintf(&detail, "\n  attempt %d: %v", i+1, err)
+	}
+
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  lastErr.Error(),
+		Detail:   detail.String(),
+	}}
+}
+
+// isRetryableApplyError reports whether the apply may succeed when retried: conflicts with concurrent changes,
+// throttling and server errors (including unreachable admission webhooks), unreachable or timed out servers, and kinds
+// which are not served yet as their CRD is still being established. Any other error, such as an invalid manifest, a
+// forbidden request or a failed rollout, fails the same way on every attempt.
+func isRetryableApplyError(err error) bool {
+	// waits time out after their own timeout, which would otherwise be repeated for every attempt
+	var timeoutErr *resource.TimeoutError
+	if goerrors.As(err, &timeoutErr) {
+		return false
+	}
+
+	if meta.IsNoMatchError(err) || goerrors.Is(err, errDiscoveryTimeout) {
+		return true
+	}
+
+	if utilnet.IsConnectionRefused(err) || utilnet.IsConnectionReset(err) || utilnet.IsProbableEOF(err) || utilnet.IsTimeout(err) {
+		return true
+	}
+
+	if errors.IsConflict(err) {
+		// field manager conflicts of server-side apply need force_conflicts, and are not resolved by retrying
+		return !hasStatusCause(err, meta_v1.CauseTypeFieldManagerConflict)
+	}
+
+	var status errors.APIStatus
+	if goerrors.As(err, &status) {
+		code := status.Status().Code
+		return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+	}
+
+	return false
+}
+
+// hasStatusCause reports whether the kubernetes API error has a cause of the type
+func hasStatusCause(err error, causeType meta_v1.CauseType) bool {
+	var status errors.APIStatus
+	if !goerrors.As(err, &status) || status.Status().Details == nil {
+		return false
+	}
+
+	for _, cause := range status.Status().Details.Causes {
+		if cause.Type == causeType {
+			return true
+		}
+	}
+	return false
+}
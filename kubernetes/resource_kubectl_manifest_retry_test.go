@@ -0,0 +1,118 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/stretchr/testify/assert"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestIsRetryableApplyError(t *testing.T) {
+	gr := k8sschema.GroupResource{Group: "example.com", Resource: "widgets"}
+	gk := k8sschema.GroupKind{Group: "example.com", Kind: "Widget"}
+
+	fieldManagerConflict := errors.NewApplyConflict([]meta_v1.StatusCause{{
+		Type:    meta_v1.CauseTypeFieldManagerConflict,
+		Message: `conflict with "other"`,
+		Field:   ".spec.size",
+	}}, "Apply failed with 1 conflict")
+
+	// a rollout which is still progressing when the wait times out
+	rolloutTimeout := resource.RetryContext(context.Background(), time.Millisecond, func() *resource.RetryError {
+		return resource.RetryableError(fmt.Errorf("Waiting for rollout to finish: 0 of 1 updated replicas are available..."))
+	})
+
+	// the job failure returned as a NonRetryableError by the rollout wait
+	jobFailure := resource.RetryContext(context.Background(), time.Minute, func() *resource.RetryError {
+		return resource.NonRetryableError(fmt.Errorf("job default/test failed: BackoffLimitExceeded"))
+	})
+
+	cases := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{"conflict", errors.NewConflict(gr, "test", fmt.Errorf("object has been modified")), true},
+		{"too many requests", errors.NewTooManyRequests("slow down", 1), true},
+		{"internal error", errors.NewInternalError(fmt.Errorf("failed calling webhook: connect: connection refused")), true},
+		{"service unavailable", errors.NewServiceUnavailable("unavailable"), true},
+		{"server timeout", errors.NewServerTimeout(gr, "create", 1), true},
+		{"no matches for kind", &meta.NoKindMatchError{GroupKind: gk, SearchedVersions: []string{"v1"}}, true},
+		{"connection refused", fmt.Errorf("dial tcp: %w", syscall.ECONNREFUSED), true},
+		{"discovery timeout", fmt.Errorf("failed to create kubernetes rest client: %w", errDiscoveryTimeout), true},
+		{"wrapped no matches for kind", fmt.Errorf("isn't valid for cluster: %w", &meta.NoKindMatchError{GroupKind: gk}), true},
+		{"wrapped server error", fmt.Errorf("failed to run apply: %w", errors.NewInternalError(fmt.Errorf("etcdserver: leader changed"))), true},
+		{"invalid", errors.NewInvalid(gk, "test", nil), false},
+		{"bad request", errors.NewBadRequest("malformed"), false},
+		{"forbidden", errors.NewForbidden(gr, "test", fmt.Errorf("denied")), false},
+		{"unauthorized", errors.NewUnauthorized("no credentials"), false},
+		{"not found", errors.NewNotFound(gr, "test"), false},
+		{"field manager conflict", fieldManagerConflict, false},
+		{"wrapped invalid", fmt.Errorf("failed to run apply: %w", errors.NewInvalid(gk, "test", nil)), false},
+		{"validation", fmt.Errorf("failed to run apply: %w", fmt.Errorf("error validating data: unknown field \"spec.sise\"")), false},
+		{"parse", fmt.Errorf("failed to parse kubernetes resource: yaml: line 2: mapping values are not allowed in this context"), false},
+		{"rollout timeout", rolloutTimeout, false},
+		{"wait timeout", fmt.Errorf("failed to wait: %w", &resource.TimeoutError{LastError: errors.NewServiceUnavailable("unavailable")}), false},
+		{"job failure", jobFailure, false},
+		{"unknown", fmt.Errorf("something went wrong"), false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.retryable, isRetryableApplyError(c.err))
+		})
+	}
+}
+
+func TestRetryApply(t *testing.T) {
+	defer func(count uint64, initial, max time.Duration) {
+		kubectlApplyRetryCount, kubectlApplyRetryInitialInterval, kubectlApplyRetryMaxInterval = count, initial, max
+	}(kubectlApplyRetryCount, kubectlApplyRetryInitialInterval, kubectlApplyRetryMaxInterval)
+	kubectlApplyRetryCount = 3
+	kubectlApplyRetryInitialInterval = time.Millisecond
+	kubectlApplyRetryMaxInterval = time.Millisecond
+
+	gr := k8sschema.GroupResource{Group: "example.com", Resource: "widgets"}
+
+	attempts := 0
+	diags := retryApply("applying", func() error {
+		attempts++
+		if attempts < 3 {
+			return errors.NewConflict(gr, "test", fmt.Errorf("object has been modified"))
+		}
+		return nil
+	})
+	assert.False(t, diags.HasError())
+	assert.Equal(t, 3, attempts)
+
+	for _, permanent := range []error{errors.NewForbidden(gr, "test", fmt.Errorf("denied")), fmt.Errorf("error validating data")} {
+		attempts = 0
+		diags = retryApply("applying", func() error {
+			attempts++
+			return permanent
+		})
+		assert.True(t, diags.HasError())
+		assert.Equal(t, 1, attempts, "%v is not retried", permanent)
+		assert.Equal(t, permanent.Error(), diags[0].Summary)
+		assert.Empty(t, diags[0].Detail)
+	}
+
+	attempts = 0
+	diags = retryApply("applying", func() error {
+		attempts++
+		return errors.NewServiceUnavailable(fmt.Sprintf("attempt %d failed", attempts))
+	})
+	assert.True(t, diags.HasError())
+	assert.Equal(t, 4, attempts)
+	assert.Equal(t, "attempt 4 failed", diags[0].Summary)
+	assert.Equal(t, "applying failed after 4 attempts:\n  attempt 1: attempt 1 failed\n  attempt 2: attempt 2 failed\n  attempt 3: attempt 3 failed\n  attempt 4: attempt 4 failed", diags[0].Detail)
+}
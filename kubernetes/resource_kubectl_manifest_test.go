@@ -11,6 +11,8 @@ import (
 	"log"
 	"os"
 	"regexp"
+	"sort"
+	"strings"
 	"testing"
 )
 
@@ -420,6 +422,261 @@ spec:
 	})
 }
 
+func TestAccKubectlSensitiveFields_secretStringData(t *testing.T) {
+
+	yaml_body := `
+apiVersion: v1
+kind: Secret
+metadata:
+  name: mysecret-string-data
+  namespace: default
+type: Opaque
+stringData:
+  PASSWORD: changeme
+`
+
+	config := fmt.Sprintf(`
+resource "kubectl_manifest" "test" {
+	yaml_body = <<EOT
+%s
+	EOT
+		}
+`, yaml_body)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckkubectlDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("kubectl_manifest.test", "yaml_body_parsed", `apiVersion: v1
+kind: Secret
+metadata:
+  name: mysecret-string-data
+  namespace: default
+stringData: (sensitive value)
+type: Opaque
+`),
+				),
+			},
+		},
+	})
+}
+
+type testResourceData map[string]interface{}
+
+func (d testResourceData) Get(key string) interface{} {
+	return d[key]
+}
+
+func TestObfuscateSensitiveFields(t *testing.T) {
+	yamlBody := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+  annotations:
+    example.com/token: secret
+    example.com/other: visible
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        env:
+        - name: USER
+          value: admin
+        - name: PASSWORD
+          value: changeme
+      - name: sidecar
+        env:
+        - name: TOKEN
+          value: abc
+`
+
+	testCases := []struct {
+		description     string
+		sensitiveFields []string
+		expected        []string
+		unexpected      []string
+	}{
+		{
+			description:     "List index",
+			sensitiveFields: []string{"spec.template.spec.containers.0.env.1.value"},
+			expected:        []string{"admin", "abc", "secret"},
+			unexpected:      []string{"changeme"},
+		},
+		{
+			description:     "Wildcards",
+			sensitiveFields: []string{"spec.template.spec.containers.*.env.*.value"},
+			expected:        []string{"USER", "TOKEN", "secret"},
+			unexpected:      []string{"admin", "changeme", "abc"},
+		},
+		{
+			description:     "List selector",
+			sensitiveFields: []string{"spec.template.spec.containers[name=app].env[name=PASSWORD].value"},
+			expected:        []string{"admin", "abc"},
+			unexpected:      []string{"changeme"},
+		},
+		{
+			description:     "Annotation key with dots",
+			sensitiveFields: []string{`metadata.annotations["example.com/token"]`},
+			expected:        []string{"visible", "changeme"},
+			unexpected:      []string{"secret"},
+		},
+		{
+			description:     "Missing field",
+			sensitiveFields: []string{"spec.missing.*.value"},
+			expected:        []string{"admin", "changeme", "abc", "secret"},
+		},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(tcase.description, func(t *testing.T) {
+			manifest, err := yaml.ParseYAML(yamlBody)
+			assert.NoError(t, err)
+
+			assert.NoError(t, obfuscateSensitiveFields(manifest, tcase.sensitiveFields))
+
+			obfuscated, err := manifest.AsYAML()
+			assert.NoError(t, err)
+			for _, s := range tcase.expected {
+				assert.Contains(t, obfuscated, s)
+			}
+			for _, s := range tcase.unexpected {
+				assert.NotContains(t, obfuscated, s)
+			}
+		})
+	}
+}
+
+func TestGetSensitiveFields_secret(t *testing.T) {
+	secret, err := yaml.ParseYAML(`
+apiVersion: v1
+kind: Secret
+metadata:
+  name: test
+`)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"data", "stringData"}, getSensitiveFields(testResourceData{"sensitive_fields": []interface{}{}}, secret))
+	assert.Equal(t, []string{"data.password"}, getSensitiveFields(testResourceData{"sensitive_fields": []interface{}{"data.password"}}, secret))
+}
+
+func TestUpgradeSelfLink(t *testing.T) {
+	manifest, err := yaml.ParseYAML(`
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: test
+  namespace: default
+`)
+	assert.NoError(t, err)
+
+	// ids are left unchanged until the provider is configured
+	legacyId := "/apis/networking.k8s.io/v1/namespaces/default/networkpolicys/test"
+	assert.Equal(t, legacyId, upgradeSelfLink(nil, legacyId, manifest))
+	assert.Equal(t, legacyId, upgradeSelfLink((*KubeProvider)(nil), legacyId, manifest))
+
+	// ids which were not built from the legacy resource name are kept
+	assert.Equal(t, "/apis/networking.k8s.io/v1/namespaces/default/networkpolicies/test", upgradeSelfLink(&KubeProvider{}, "/apis/networking.k8s.io/v1/namespaces/default/networkpolicies/test", manifest))
+}
+
+func TestAccKubectlManifest_selfLinkPlural(t *testing.T) {
+	name := fmt.Sprintf("plural-%s", acctest.RandStringFromCharSet(10, acctest.CharSetAlphaNum))
+
+	config := fmt.Sprintf(`
+resource "kubectl_manifest" "test" {
+	yaml_body = <<YAML
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: %s
+  namespace: default
+spec:
+  podSelector: {}
+YAML
+}
+`, name)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckkubectlDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("kubectl_manifest.test", "id", fmt.Sprintf("/apis/networking.k8s.io/v1/namespaces/default/networkpolicies/%s", name)),
+				),
+			},
+		},
+	})
+}
+
+func TestAccKubectlManifest_selfLinkPluralCRD(t *testing.T) {
+	group := fmt.Sprintf("plural%s.example.com", acctest.RandStringFromCharSet(10, acctest.CharSetAlpha))
+
+	// the custom resource is created in the same run as its CRD, after the rest mapper has been used for the CRD
+	config := fmt.Sprintf(`
+resource "kubectl_manifest" "crd" {
+	yaml_body = <<YAML
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: octopodes.%[1]s
+spec:
+  group: %[1]s
+  scope: Namespaced
+  names:
+    plural: octopodes
+    singular: octopus
+    kind: Octopus
+  versions:
+    - name: v1
+      served: true
+      storage: true
+      schema:
+        openAPIV3Schema:
+          type: object
+          x-kubernetes-preserve-unknown-fields: true
+YAML
+}
+
+resource "kubectl_manifest" "test" {
+	depends_on = [kubectl_manifest.crd]
+	yaml_body  = <<YAML
+apiVersion: %[1]s/v1
+kind: Octopus
+metadata:
+  name: test
+  namespace: default
+YAML
+}
+`, group)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckkubectlDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("kubectl_manifest.test", "id", fmt.Sprintf("/apis/%s/v1/namespaces/default/octopodes/test", group)),
+				),
+			},
+			{
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: false,
+			},
+		},
+	})
+}
+
 func TestAccKubectlWithoutValidation(t *testing.T) {
 
 	yaml_body := `
@@ -906,16 +1163,105 @@ func TestGetLiveManifestFilteredForUserProvidedOnly(t *testing.T) {
 			log.SetOutput(&out)
 			defer log.SetOutput(os.Stderr)
 
-			fields := getLiveManifestFields_WithIgnoredFields(tcase.ignored, userProvided, liveManifest)
+			fields, drift := getLiveManifestFields_WithIgnoredFields(tcase.ignored, userProvided, liveManifest)
 			assert.Equal(t, tcase.expectedFields, fields, "Expect the builder output to match")
 			fingerprint := getFingerprint(fields)
 			assert.Equal(t, tcase.expectedFingerprint, fingerprint, "Expect the builder output to match")
 
 			if tcase.expectedDrift {
 				assert.Contains(t, out.String(), "yaml drift", "Should have drift detected")
+				assert.NotEmpty(t, drift, "Should have drifted fields")
 			} else {
 				assert.NotContains(t, out.String(), "yaml drift", "Should not have drift detected")
+				assert.Empty(t, drift, "Should not have drifted fields")
+			}
+		})
+	}
+}
+
+func TestGetLiveManifestIgnoreFieldSelectors(t *testing.T) {
+	userProvided := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				"example.com/foo": "user",
+				"example.com/bar": "user",
+			},
+		},
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "app", "image": "app:1", "resources": map[string]interface{}{"cpu": "1"}},
+				map[string]interface{}{"name": "sidecar", "image": "sidecar:1", "resources": map[string]interface{}{"cpu": "1"}},
+			},
+		},
+	}
+
+	liveManifest := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				"example.com/foo": "changed",
+				"example.com/bar": "changed",
+			},
+		},
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "app", "image": "app:2", "resources": map[string]interface{}{"cpu": "2"}},
+				map[string]interface{}{"name": "sidecar", "image": "sidecar:2", "resources": map[string]interface{}{"cpu": "2"}},
+			},
+		},
+	}
+
+	testCases := []struct {
+		ignored       []string
+		expectedDrift []string
+	}{
+		{
+			ignored: nil,
+			expectedDrift: []string{
+				"metadata.annotations.example.com/bar",
+				"metadata.annotations.example.com/foo",
+				"spec.containers.0.image",
+				"spec.containers.0.resources.cpu",
+				"spec.containers.1.image",
+				"spec.containers.1.resources.cpu",
+			},
+		},
+		{
+			ignored: []string{"spec.containers.*.image", `metadata.annotations["example.com/foo"]`},
+			expectedDrift: []string{
+				"metadata.annotations.example.com/bar",
+				"spec.containers.0.resources.cpu",
+				"spec.containers.1.resources.cpu",
+			},
+		},
+		{
+			ignored: []string{"spec.containers[name=app]", "metadata.annotations.example.com/bar", "metadata.annotations['example.com/foo']"},
+			expectedDrift: []string{
+				"spec.containers.1.image",
+				"spec.containers.1.resources.cpu",
+			},
+		},
+		{
+			ignored: []string{"spec.containers[name=sidecar].resources", "spec.containers[0].image", "metadata.annotations[*]"},
+			expectedDrift: []string{
+				"spec.containers.0.resources.cpu",
+				"spec.containers.1.image",
+			},
+		},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(strings.Join(tcase.ignored, ","), func(t *testing.T) {
+			user := yaml.NewFromUnstructured(&unstructured.Unstructured{Object: userProvided})
+			live := yaml.NewFromUnstructured(&unstructured.Unstructured{Object: liveManifest})
+
+			_, drift := getLiveManifestFields_WithIgnoredFields(tcase.ignored, user, live)
+
+			var driftedKeys []string
+			for k := range drift {
+				driftedKeys = append(driftedKeys, k)
 			}
+			sort.Strings(driftedKeys)
+			assert.Equal(t, tcase.expectedDrift, driftedKeys)
 		})
 	}
 }
@@ -1133,3 +1479,116 @@ status:
 
 	return manifest
 }
+
+func TestAccKubectlDryRunOnPlan_validationFailure(t *testing.T) {
+
+	config := `
+resource "kubectl_manifest" "test" {
+  dry_run_on_plan = true
+  yaml_body = <<YAML
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: ingress
+spec:
+  rules:
+    - host: "test-a.proxypile.tk"
+      http:
+        paths:
+          - path: /
+            pathType: Prefix
+            backend:
+              service:
+                name: nginx.test-a.svc.cluster.local
+                port:
+                  number: 8080
+YAML
+}
+`
+	expectedError, _ := regexp.Compile(".*failed server-side dry-run.*")
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				PlanOnly:    true,
+				ExpectError: expectedError,
+				Config:      config,
+			},
+		},
+	})
+}
+
+func TestAccKubectlDryRunOnPlan_secret(t *testing.T) {
+
+	config := `
+resource "kubectl_manifest" "test" {
+  dry_run_on_plan = true
+  yaml_body = <<YAML
+apiVersion: v1
+kind: Secret
+metadata:
+  name: dry-run-secret
+  namespace: default
+data:
+  PASSWORD: MWYyZDFlMmU2N2Rm
+YAML
+}
+`
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckkubectlDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("kubectl_manifest.test", "yaml_dry_run", `apiVersion: v1
+data: (sensitive value)
+kind: Secret
+metadata:
+  name: dry-run-secret
+  namespace: default
+type: Opaque
+`),
+				),
+			},
+		},
+	})
+}
+
+func TestGetLiveManifestDrift(t *testing.T) {
+	userProvided := yaml.NewFromUnstructured(&unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": 3,
+			"paused":   false,
+			"selector": "app",
+		},
+		"data": map[string]interface{}{
+			"password": "c2VjcmV0",
+		},
+	}})
+	liveManifest := yaml.NewFromUnstructured(&unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": 5,
+			"paused":   false,
+		},
+		"data": map[string]interface{}{
+			"password": "Y2hhbmdlZA==",
+		},
+	}})
+
+	_, drift := getLiveManifestFields_WithIgnoredFields(nil, userProvided, liveManifest)
+	assert.Equal(t, map[string]string{
+		"spec.replicas": "5",
+		"spec.selector": "",
+		"data.password": "Y2hhbmdlZA==",
+	}, drift)
+
+	masked := maskSensitiveDrift(drift, userProvided, []string{"data"})
+	assert.Equal(t, map[string]string{
+		"spec.replicas": "5",
+		"spec.selector": "",
+		"data.password": "(sensitive value)",
+	}, masked)
+}
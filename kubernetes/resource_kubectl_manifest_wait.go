@@ -0,0 +1,535 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/gavinbunney/terraform-provider-kubectl/flatten"
+	"github.com/gavinbunney/terraform-provider-kubectl/yaml"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	batch_v1 "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	meta_v1_unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	apiregistration "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+)
+
+const (
+	// https://github.com/kubernetes/kubernetes/blob/master/pkg/controller/deployment/util/deployment_util.go#L93
+	TimedOutReason = "ProgressDeadlineExceeded"
+)
+
+// rolloutReadinessCheck describes how to wait for a particular type of kubernetes resource to
+// finish rolling out. The first check which matches the manifest is used.
+type rolloutReadinessCheck struct {
+	description string
+	matches     func(manifest *yaml.Manifest) bool
+	retryFunc   func(ctx context.Context, provider *KubeProvider, manifest *yaml.Manifest) resource.RetryFunc
+}
+
+var rolloutReadinessChecks = []rolloutReadinessCheck{
+	{
+		description: "deployment",
+		matches: func(manifest *yaml.Manifest) bool {
+			return manifest.GetKind() == "Deployment"
+		},
+		retryFunc: func(ctx context.Context, provider *KubeProvider, manifest *yaml.Manifest) resource.RetryFunc {
+			return waitForDeploymentReplicasFunc(ctx, provider, manifest.GetNamespace(), manifest.GetName())
+		},
+	},
+	{
+		description: "statefulset",
+		matches: func(manifest *yaml.Manifest) bool {
+			return manifest.GetKind() == "StatefulSet" && manifest.GetAPIVersion() == "apps/v1"
+		},
+		retryFunc: func(ctx context.Context, provider *KubeProvider, manifest *yaml.Manifest) resource.RetryFunc {
+			return waitForStatefulSetReplicasFunc(ctx, provider, manifest.GetNamespace(), manifest.GetName())
+		},
+	},
+	{
+		description: "daemonset",
+		matches: func(manifest *yaml.Manifest) bool {
+			return manifest.GetKind() == "DaemonSet" && manifest.GetAPIVersion() == "apps/v1"
+		},
+		retryFunc: func(ctx context.Context, provider *KubeProvider, manifest *yaml.Manifest) resource.RetryFunc {
+			return waitForDaemonSetReplicasFunc(ctx, provider, manifest.GetNamespace(), manifest.GetName())
+		},
+	},
+	{
+		description: "replicaset",
+		matches: func(manifest *yaml.Manifest) bool {
+			return manifest.GetKind() == "ReplicaSet" && manifest.GetAPIVersion() == "apps/v1"
+		},
+		retryFunc: func(ctx context.Context, provider *KubeProvider, manifest *yaml.Manifest) resource.RetryFunc {
+			return waitForReplicaSetReplicasFunc(ctx, provider, manifest.GetNamespace(), manifest.GetName())
+		},
+	},
+	{
+		description: "job",
+		matches: func(manifest *yaml.Manifest) bool {
+			return manifest.GetKind() == "Job" && manifest.GetAPIVersion() == "batch/v1"
+		},
+		retryFunc: func(ctx context.Context, provider *KubeProvider, manifest *yaml.Manifest) resource.RetryFunc {
+			return waitForJobCompleteFunc(ctx, provider, manifest.GetNamespace(), manifest.GetName())
+		},
+	},
+	{
+		description: "APIService",
+		matches: func(manifest *yaml.Manifest) bool {
+			return manifest.GetKind() == "APIService" && manifest.GetAPIVersion() == "apiregistration.k8s.io/v1"
+		},
+		retryFunc: func(ctx context.Context, provider *KubeProvider, manifest *yaml.Manifest) resource.RetryFunc {
+			return waitForAPIServiceAvailableFunc(ctx, provider, manifest.GetName())
+		},
+	},
+}
+
+// getRolloutReadinessCheck returns the readiness check to use for the manifest, if any
+func getRolloutReadinessCheck(manifest *yaml.Manifest) (*rolloutReadinessCheck, bool) {
+	for i := range rolloutReadinessChecks {
+		if rolloutReadinessChecks[i].matches(manifest) {
+			return &rolloutReadinessChecks[i], true
+		}
+	}
+	return nil, false
+}
+
+var waitForSchema = &schema.Schema{
+	Type:        schema.TypeList,
+	Description: "Wait for the listed status conditions and field values to be present on the live resource after apply.",
+	Optional:    true,
+	MaxItems:    1,
+	Elem: &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"condition": {
+				Type:        schema.TypeList,
+				Description: "Status condition (from status.conditions) which must be present with the given status.",
+				Optional:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type": {
+							Type:        schema.TypeString,
+							Description: "The type of the condition, e.g. Ready or Established.",
+							Required:    true,
+						},
+						"status": {
+							Type:        schema.TypeString,
+							Description: "The expected status of the condition. Defaults to True.",
+							Optional:    true,
+							Default:     "True",
+						},
+					},
+				},
+			},
+			"field": {
+				Type:        schema.TypeList,
+				Description: "Field of the live resource which must match the given value.",
+				Optional:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"key": {
+							Type:        schema.TypeString,
+							Description: "Path to the field, e.g. status.phase or status.containerStatuses[0].ready.",
+							Required:    true,
+						},
+						"value": {
+							Type:        schema.TypeString,
+							Description: "The value to match.",
+							Required:    true,
+						},
+						"value_type": {
+							Type:         schema.TypeString,
+							Description:  "How to match the value, either eq (exact match) or regex. Defaults to eq.",
+							Optional:     true,
+							Default:      waitForValueTypeEquals,
+							ValidateFunc: validation.StringInSlice([]string{waitForValueTypeEquals, waitForValueTypeRegex}, false),
+						},
+					},
+				},
+			},
+		},
+	},
+}
+
+const (
+	waitForValueTypeEquals = "eq"
+	waitForValueTypeRegex  = "regex"
+)
+
+type waitForCondition struct {
+	Type   string
+	Status string
+}
+
+type waitForField struct {
+	Key       string
+	Value     string
+	ValueType string
+}
+
+type waitForConfig struct {
+	Conditions []waitForCondition
+	Fields     []waitForField
+}
+
+func (w *waitForConfig) IsEmpty() bool {
+	return w == nil || (len(w.Conditions) == 0 && len(w.Fields) == 0)
+}
+
+// expandWaitFor converts the raw wait_for schema block into a waitForConfig
+func expandWaitFor(raw []interface{}) *waitForConfig {
+	config := &waitForConfig{}
+	if len(raw) == 0 || raw[0] == nil {
+		return config
+	}
+
+	block := raw[0].(map[string]interface{})
+	if conditions, ok := block["condition"].([]interface{}); ok {
+		for _, c := range conditions {
+			if c == nil {
+				continue
+			}
+			condition := c.(map[string]interface{})
+			config.Conditions = append(config.Conditions, waitForCondition{
+				Type:   condition["type"].(string),
+				Status: condition["status"].(string),
+			})
+		}
+	}
+
+	if fields, ok := block["field"].([]interface{}); ok {
+		for _, f := range fields {
+			if f == nil {
+				continue
+			}
+			field := f.(map[string]interface{})
+			config.Fields = append(config.Fields, waitForField{
+				Key:       field["key"].(string),
+				Value:     field["value"].(string),
+				ValueType: field["value_type"].(string),
+			})
+		}
+	}
+
+	return config
+}
+
+var waitForKeyIndexRegex = regexp.MustCompile(`\[(\d+)\]`)
+
+// normalizeWaitForKey converts JSONPath-style keys into the flattened dot syntax,
+// e.g. `.status.containerStatuses[0].ready` becomes `status.containerStatuses.0.ready`
+func normalizeWaitForKey(key string) string {
+	key = strings.TrimPrefix(key, "$")
+	key = waitForKeyIndexRegex.ReplaceAllString(key, ".$1")
+	key = strings.ReplaceAll(key, "..", ".")
+	return strings.Trim(key, ".")
+}
+
+// evaluateWaitFor checks the live object against the configured conditions and fields.
+// It returns a description of the first unmet requirement, or an empty string when all are met.
+func evaluateWaitFor(config *waitForConfig, live *meta_v1_unstruct.Unstructured) (string, error) {
+	if len(config.Conditions) > 0 {
+		conditions, _, err := meta_v1_unstruct.NestedSlice(live.Object, "status", "conditions")
+		if err != nil {
+			return "", fmt.Errorf("failed to read status.conditions: %+v", err)
+		}
+
+		for _, expected := range config.Conditions {
+			actualStatus := ""
+			for _, c := range conditions {
+				condition, ok := c.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if fmt.Sprintf("%v", condition["type"]) == expected.Type {
+					actualStatus = fmt.Sprintf("%v", condition["status"])
+					break
+				}
+			}
+
+			if actualStatus != expected.Status {
+				return fmt.Sprintf("condition %s to be %s (currently %q)", expected.Type, expected.Status, actualStatus), nil
+			}
+		}
+	}
+
+	if len(config.Fields) > 0 {
+		flattenedLive := flatten.Flatten(live.Object)
+		for _, expected := range config.Fields {
+			key := normalizeWaitForKey(expected.Key)
+			actual, exists := flattenedLive[key]
+			if !exists {
+				return fmt.Sprintf("field %s to be present", key), nil
+			}
+
+			switch expected.ValueType {
+			case waitForValueTypeRegex:
+				matcher, err := regexp.Compile(expected.Value)
+				if err != nil {
+					return "", fmt.Errorf("invalid regex %q for field %s: %+v", expected.Value, key, err)
+				}
+				if !matcher.MatchString(actual) {
+					return fmt.Sprintf("field %s to match %s (currently %q)", key, expected.Value, actual), nil
+				}
+			default:
+				if actual != expected.Value {
+					return fmt.Sprintf("field %s to be %s (currently %q)", key, expected.Value, actual), nil
+				}
+			}
+		}
+	}
+
+	return "", nil
+}
+
+func waitForConditionsFunc(ctx context.Context, client dynamic.ResourceInterface, manifest *yaml.Manifest, config *waitForConfig) resource.RetryFunc {
+	return func() *resource.RetryError {
+
+		live, err := client.Get(ctx, manifest.GetName(), meta_v1.GetOptions{})
+		if err != nil {
+			return resource.NonRetryableError(err)
+		}
+
+		pending, err := evaluateWaitFor(config, live)
+		if err != nil {
+			return resource.NonRetryableError(err)
+		}
+
+		if pending != "" {
+			log.Printf("[DEBUG] %v waiting for %s", manifest, pending)
+			return resource.RetryableError(fmt.Errorf("Waiting for %v: %s", manifest, pending))
+		}
+
+		return nil
+	}
+}
+
+// GetDeploymentCondition returns the condition with the provided type.
+// Borrowed from: https://github.com/kubernetes/kubernetes/blob/master/pkg/controller/deployment/util/deployment_util.go#L135
+func GetDeploymentCondition(status apps_v1.DeploymentStatus, condType apps_v1.DeploymentConditionType) *apps_v1.DeploymentCondition {
+	for i := range status.Conditions {
+		c := status.Conditions[i]
+		if c.Type == condType {
+			return &c
+		}
+	}
+	return nil
+}
+
+func waitForDeploymentReplicasFunc(ctx context.Context, provider *KubeProvider, ns, name string) resource.RetryFunc {
+	return func() *resource.RetryError {
+
+		// Query the deployment to get a status update.
+		clientset, err := provider.ToClientset()
+		if err != nil {
+			return resource.NonRetryableError(err)
+		}
+
+		dply, err := clientset.AppsV1().Deployments(ns).Get(ctx, name, meta_v1.GetOptions{})
+		if err != nil {
+			return resource.NonRetryableError(err)
+		}
+
+		if dply.Generation <= dply.Status.ObservedGeneration {
+			cond := GetDeploymentCondition(dply.Status, apps_v1.DeploymentProgressing)
+			if cond != nil && cond.Reason == TimedOutReason {
+				err := fmt.Errorf("Deployment exceeded its progress deadline: %v", cond.String())
+				return resource.NonRetryableError(err)
+			}
+
+			if dply.Status.UpdatedReplicas < *dply.Spec.Replicas {
+				return resource.RetryableError(fmt.Errorf("Waiting for rollout to finish: %d out of %d new replicas have been updated...", dply.Status.UpdatedReplicas, dply.Spec.Replicas))
+			}
+
+			if dply.Status.Replicas > dply.Status.UpdatedReplicas {
+				return resource.RetryableError(fmt.Errorf("Waiting for rollout to finish: %d old replicas are pending termination...", dply.Status.Replicas-dply.Status.UpdatedReplicas))
+			}
+
+			if dply.Status.AvailableReplicas < dply.Status.UpdatedReplicas {
+				return resource.RetryableError(fmt.Errorf("Waiting for rollout to finish: %d of %d updated replicas are available...", dply.Status.AvailableReplicas, dply.Status.UpdatedReplicas))
+			}
+		} else if dply.Status.ObservedGeneration == 0 {
+			return resource.RetryableError(fmt.Errorf("Waiting for rollout to start"))
+		}
+		return nil
+	}
+}
+
+// waitForStatefulSetReplicasFunc mirrors the `kubectl rollout status` checks for StatefulSets.
+// Borrowed from: https://github.com/kubernetes/kubectl/blob/master/pkg/polymorphichelpers/rollout_status.go
+func waitForStatefulSetReplicasFunc(ctx context.Context, provider *KubeProvider, ns, name string) resource.RetryFunc {
+	return func() *resource.RetryError {
+
+		clientset, err := provider.ToClientset()
+		if err != nil {
+			return resource.NonRetryableError(err)
+		}
+
+		sts, err := clientset.AppsV1().StatefulSets(ns).Get(ctx, name, meta_v1.GetOptions{})
+		if err != nil {
+			return resource.NonRetryableError(err)
+		}
+
+		if sts.Spec.UpdateStrategy.Type == apps_v1.OnDeleteStatefulSetStrategyType {
+			log.Printf("[DEBUG] StatefulSet %s/%s uses the OnDelete update strategy, skipping rollout wait", ns, name)
+			return nil
+		}
+
+		if sts.Status.ObservedGeneration == 0 || sts.Generation > sts.Status.ObservedGeneration {
+			return resource.RetryableError(fmt.Errorf("Waiting for statefulset spec update to be observed..."))
+		}
+
+		if sts.Spec.Replicas != nil && sts.Status.ReadyReplicas < *sts.Spec.Replicas {
+			return resource.RetryableError(fmt.Errorf("Waiting for %d pods to be ready...", *sts.Spec.Replicas-sts.Status.ReadyReplicas))
+		}
+
+		if sts.Spec.UpdateStrategy.Type == apps_v1.RollingUpdateStatefulSetStrategyType && sts.Spec.UpdateStrategy.RollingUpdate != nil {
+			if sts.Spec.Replicas != nil && sts.Spec.UpdateStrategy.RollingUpdate.Partition != nil && *sts.Spec.UpdateStrategy.RollingUpdate.Partition > 0 {
+				partitionedReplicas := *sts.Spec.Replicas - *sts.Spec.UpdateStrategy.RollingUpdate.Partition
+				if sts.Status.UpdatedReplicas < partitionedReplicas {
+					return resource.RetryableError(fmt.Errorf("Waiting for partitioned roll out to finish: %d out of %d new pods have been updated...", sts.Status.UpdatedReplicas, partitionedReplicas))
+				}
+				return nil
+			}
+		}
+
+		if sts.Status.UpdateRevision != sts.Status.CurrentRevision {
+			return resource.RetryableError(fmt.Errorf("Waiting for statefulset rolling update to complete %d pods at revision %s...", sts.Status.UpdatedReplicas, sts.Status.UpdateRevision))
+		}
+
+		return nil
+	}
+}
+
+// waitForDaemonSetReplicasFunc mirrors the `kubectl rollout status` checks for DaemonSets.
+// Borrowed from: https://github.com/kubernetes/kubectl/blob/master/pkg/polymorphichelpers/rollout_status.go
+func waitForDaemonSetReplicasFunc(ctx context.Context, provider *KubeProvider, ns, name string) resource.RetryFunc {
+	return func() *resource.RetryError {
+
+		clientset, err := provider.ToClientset()
+		if err != nil {
+			return resource.NonRetryableError(err)
+		}
+
+		daemon, err := clientset.AppsV1().DaemonSets(ns).Get(ctx, name, meta_v1.GetOptions{})
+		if err != nil {
+			return resource.NonRetryableError(err)
+		}
+
+		if daemon.Spec.UpdateStrategy.Type == apps_v1.OnDeleteDaemonSetStrategyType {
+			log.Printf("[DEBUG] DaemonSet %s/%s uses the OnDelete update strategy, skipping rollout wait", ns, name)
+			return nil
+		}
+
+		if daemon.Generation > daemon.Status.ObservedGeneration {
+			return resource.RetryableError(fmt.Errorf("Waiting for daemon set spec update to be observed..."))
+		}
+
+		if daemon.Status.UpdatedNumberScheduled < daemon.Status.DesiredNumberScheduled {
+			return resource.RetryableError(fmt.Errorf("Waiting for daemon set %q rollout to finish: %d out of %d new pods have been updated...", name, daemon.Status.UpdatedNumberScheduled, daemon.Status.DesiredNumberScheduled))
+		}
+
+		if daemon.Status.NumberAvailable < daemon.Status.DesiredNumberScheduled {
+			return resource.RetryableError(fmt.Errorf("Waiting for daemon set %q rollout to finish: %d of %d updated pods are available...", name, daemon.Status.NumberAvailable, daemon.Status.DesiredNumberScheduled))
+		}
+
+		return nil
+	}
+}
+
+func waitForReplicaSetReplicasFunc(ctx context.Context, provider *KubeProvider, ns, name string) resource.RetryFunc {
+	return func() *resource.RetryError {
+
+		clientset, err := provider.ToClientset()
+		if err != nil {
+			return resource.NonRetryableError(err)
+		}
+
+		rs, err := clientset.AppsV1().ReplicaSets(ns).Get(ctx, name, meta_v1.GetOptions{})
+		if err != nil {
+			return resource.NonRetryableError(err)
+		}
+
+		if rs.Status.ObservedGeneration == 0 || rs.Generation > rs.Status.ObservedGeneration {
+			return resource.RetryableError(fmt.Errorf("Waiting for replica set spec update to be observed..."))
+		}
+
+		desiredReplicas := int32(1)
+		if rs.Spec.Replicas != nil {
+			desiredReplicas = *rs.Spec.Replicas
+		}
+
+		if rs.Status.ReadyReplicas < desiredReplicas {
+			return resource.RetryableError(fmt.Errorf("Waiting for replica set %q to be ready: %d of %d replicas are ready...", name, rs.Status.ReadyReplicas, desiredReplicas))
+		}
+
+		if rs.Status.AvailableReplicas < desiredReplicas {
+			return resource.RetryableError(fmt.Errorf("Waiting for replica set %q to be ready: %d of %d replicas are available...", name, rs.Status.AvailableReplicas, desiredReplicas))
+		}
+
+		return nil
+	}
+}
+
+// waitForJobCompleteFunc waits for the Job to either complete or fail. A failed Job (such as
+// exceeding its backoffLimit or activeDeadlineSeconds) will not be retried.
+func waitForJobCompleteFunc(ctx context.Context, provider *KubeProvider, ns, name string) resource.RetryFunc {
+	return func() *resource.RetryError {
+
+		clientset, err := provider.ToClientset()
+		if err != nil {
+			return resource.NonRetryableError(err)
+		}
+
+		job, err := clientset.BatchV1().Jobs(ns).Get(ctx, name, meta_v1.GetOptions{})
+		if err != nil {
+			return resource.NonRetryableError(err)
+		}
+
+		for _, cond := range job.Status.Conditions {
+			if cond.Status != core_v1.ConditionTrue {
+				continue
+			}
+
+			switch cond.Type {
+			case batch_v1.JobComplete:
+				return nil
+			case batch_v1.JobFailed:
+				return resource.NonRetryableError(fmt.Errorf("Job %s/%s failed: %s %s", ns, name, cond.Reason, cond.Message))
+			}
+		}
+
+		return resource.RetryableError(fmt.Errorf("Waiting for job %q to complete: %d active, %d succeeded, %d failed...", name, job.Status.Active, job.Status.Succeeded, job.Status.Failed))
+	}
+}
+
+func waitForAPIServiceAvailableFunc(ctx context.Context, provider *KubeProvider, name string) resource.RetryFunc {
+	return func() *resource.RetryError {
+
+		aggregatorClientset, err := provider.ToAggregatorClientset()
+		if err != nil {
+			return resource.NonRetryableError(err)
+		}
+
+		apiService, err := aggregatorClientset.ApiregistrationV1().APIServices().Get(ctx, name, meta_v1.GetOptions{})
+		if err != nil {
+			return resource.NonRetryableError(err)
+		}
+
+		for i := range apiService.Status.Conditions {
+			if apiService.Status.Conditions[i].Type == apiregistration.Available {
+				return nil
+			}
+		}
+
+		return resource.RetryableError(fmt.Errorf("Waiting for APIService %v to be Available", name))
+	}
+}
@@ -0,0 +1,320 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/stretchr/testify/assert"
+	apps_v1 "k8s.io/api/apps/v1"
+	batch_v1 "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	restclient "k8s.io/client-go/rest"
+)
+
+func TestNormalizeWaitForKey(t *testing.T) {
+	testCases := []struct {
+		key      string
+		expected string
+	}{
+		{key: "status.phase", expected: "status.phase"},
+		{key: ".status.phase", expected: "status.phase"},
+		{key: "$.status.phase", expected: "status.phase"},
+		{key: "status.containerStatuses[0].ready", expected: "status.containerStatuses.0.ready"},
+		{key: "status.containerStatuses.[0].ready", expected: "status.containerStatuses.0.ready"},
+		{key: "status.containerStatuses.0.ready", expected: "status.containerStatuses.0.ready"},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(tcase.key, func(t *testing.T) {
+			assert.Equal(t, tcase.expected, normalizeWaitForKey(tcase.key))
+		})
+	}
+}
+
+func TestEvaluateWaitFor(t *testing.T) {
+	live := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"phase": "Running",
+			"podIP": "10.0.0.12",
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "True"},
+				map[string]interface{}{"type": "Established", "status": "False"},
+			},
+			"containerStatuses": []interface{}{
+				map[string]interface{}{"name": "app", "ready": true},
+			},
+		},
+	}}
+
+	testCases := []struct {
+		description   string
+		config        *waitForConfig
+		expectPending bool
+		expectError   bool
+	}{
+		{
+			description: "Condition met",
+			config:      &waitForConfig{Conditions: []waitForCondition{{Type: "Ready", Status: "True"}}},
+		},
+		{
+			description:   "Condition with different status",
+			config:        &waitForConfig{Conditions: []waitForCondition{{Type: "Established", Status: "True"}}},
+			expectPending: true,
+		},
+		{
+			description:   "Condition missing",
+			config:        &waitForConfig{Conditions: []waitForCondition{{Type: "Available", Status: "True"}}},
+			expectPending: true,
+		},
+		{
+			description: "Field equals",
+			config:      &waitForConfig{Fields: []waitForField{{Key: "status.phase", Value: "Running", ValueType: waitForValueTypeEquals}}},
+		},
+		{
+			description: "Field in list equals",
+			config:      &waitForConfig{Fields: []waitForField{{Key: "status.containerStatuses[0].ready", Value: "true", ValueType: waitForValueTypeEquals}}},
+		},
+		{
+			description:   "Field not equal",
+			config:        &waitForConfig{Fields: []waitForField{{Key: "status.phase", Value: "Succeeded", ValueType: waitForValueTypeEquals}}},
+			expectPending: true,
+		},
+		{
+			description:   "Field missing",
+			config:        &waitForConfig{Fields: []waitForField{{Key: "status.hostIP", Value: "", ValueType: waitForValueTypeEquals}}},
+			expectPending: true,
+		},
+		{
+			description: "Field regex",
+			config:      &waitForConfig{Fields: []waitForField{{Key: "status.podIP", Value: `^(\d+(\.|$)){4}`, ValueType: waitForValueTypeRegex}}},
+		},
+		{
+			description: "Invalid regex",
+			config:      &waitForConfig{Fields: []waitForField{{Key: "status.podIP", Value: `(`, ValueType: waitForValueTypeRegex}}},
+			expectError: true,
+		},
+		{
+			description: "Conditions and fields met",
+			config: &waitForConfig{
+				Conditions: []waitForCondition{{Type: "Ready", Status: "True"}},
+				Fields:     []waitForField{{Key: "status.phase", Value: "Running", ValueType: waitForValueTypeEquals}},
+			},
+		},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(tcase.description, func(t *testing.T) {
+			pending, err := evaluateWaitFor(tcase.config, live)
+			if tcase.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			if tcase.expectPending {
+				assert.NotEmpty(t, pending, "Expected wait to be pending")
+			} else {
+				assert.Empty(t, pending, "Expected wait to be complete")
+			}
+		})
+	}
+}
+
+func TestWaitForRolloutFuncs(t *testing.T) {
+	replicas := func(n int32) *int32 { return &n }
+	objectMeta := meta_v1.ObjectMeta{Name: "test", Namespace: "default", Generation: 2}
+
+	statefulSet := func(partition *int32, status apps_v1.StatefulSetStatus) *apps_v1.StatefulSet {
+		return &apps_v1.StatefulSet{
+			ObjectMeta: objectMeta,
+			Spec: apps_v1.StatefulSetSpec{
+				Replicas: replicas(3),
+				UpdateStrategy: apps_v1.StatefulSetUpdateStrategy{
+					Type:          apps_v1.RollingUpdateStatefulSetStrategyType,
+					RollingUpdate: &apps_v1.RollingUpdateStatefulSetStrategy{Partition: partition},
+				},
+			},
+			Status: status,
+		}
+	}
+
+	daemonSet := func(status apps_v1.DaemonSetStatus) *apps_v1.DaemonSet {
+		return &apps_v1.DaemonSet{
+			ObjectMeta: objectMeta,
+			Spec: apps_v1.DaemonSetSpec{
+				UpdateStrategy: apps_v1.DaemonSetUpdateStrategy{Type: apps_v1.RollingUpdateDaemonSetStrategyType},
+			},
+			Status: status,
+		}
+	}
+
+	job := func(conditions ...batch_v1.JobCondition) *batch_v1.Job {
+		return &batch_v1.Job{ObjectMeta: objectMeta, Status: batch_v1.JobStatus{Conditions: conditions}}
+	}
+
+	testCases := []struct {
+		description string
+		object      runtime.Object
+		waitFunc    func(context.Context, *KubeProvider, string, string) resource.RetryFunc
+		// expectRetryable is only checked when an error is expected
+		expectError     bool
+		expectRetryable bool
+	}{
+		{
+			description: "statefulset rolled out",
+			object:      statefulSet(nil, apps_v1.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 3, UpdatedReplicas: 3, CurrentRevision: "r2", UpdateRevision: "r2"}),
+			waitFunc:    waitForStatefulSetReplicasFunc,
+		},
+		{
+			description:     "statefulset with stale observedGeneration",
+			object:          statefulSet(nil, apps_v1.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 3, UpdatedReplicas: 3, CurrentRevision: "r2", UpdateRevision: "r2"}),
+			waitFunc:        waitForStatefulSetReplicasFunc,
+			expectError:     true,
+			expectRetryable: true,
+		},
+		{
+			description:     "statefulset rolling update in progress",
+			object:          statefulSet(nil, apps_v1.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 3, UpdatedReplicas: 1, CurrentRevision: "r1", UpdateRevision: "r2"}),
+			waitFunc:        waitForStatefulSetReplicasFunc,
+			expectError:     true,
+			expectRetryable: true,
+		},
+		{
+			description: "partitioned statefulset rolled out up to its partition",
+			object:      statefulSet(replicas(2), apps_v1.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 3, UpdatedReplicas: 1, CurrentRevision: "r1", UpdateRevision: "r2"}),
+			waitFunc:    waitForStatefulSetReplicasFunc,
+		},
+		{
+			description:     "partitioned statefulset rolling out",
+			object:          statefulSet(replicas(1), apps_v1.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 3, UpdatedReplicas: 1, CurrentRevision: "r1", UpdateRevision: "r2"}),
+			waitFunc:        waitForStatefulSetReplicasFunc,
+			expectError:     true,
+			expectRetryable: true,
+		},
+		{
+			description: "daemonset rolled out",
+			object:      daemonSet(apps_v1.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 3}),
+			waitFunc:    waitForDaemonSetReplicasFunc,
+		},
+		{
+			description:     "daemonset with stale observedGeneration",
+			object:          daemonSet(apps_v1.DaemonSetStatus{ObservedGeneration: 1, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 3}),
+			waitFunc:        waitForDaemonSetReplicasFunc,
+			expectError:     true,
+			expectRetryable: true,
+		},
+		{
+			description:     "daemonset with pods not updated",
+			object:          daemonSet(apps_v1.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 2, NumberAvailable: 3}),
+			waitFunc:        waitForDaemonSetReplicasFunc,
+			expectError:     true,
+			expectRetryable: true,
+		},
+		{
+			description:     "daemonset with updated pods not available",
+			object:          daemonSet(apps_v1.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 2}),
+			waitFunc:        waitForDaemonSetReplicasFunc,
+			expectError:     true,
+			expectRetryable: true,
+		},
+		{
+			description: "replicaset ready",
+			object: &apps_v1.ReplicaSet{
+				ObjectMeta: objectMeta,
+				Spec:       apps_v1.ReplicaSetSpec{Replicas: replicas(2)},
+				Status:     apps_v1.ReplicaSetStatus{ObservedGeneration: 2, ReadyReplicas: 2, AvailableReplicas: 2},
+			},
+			waitFunc: waitForReplicaSetReplicasFunc,
+		},
+		{
+			description: "replicaset with stale observedGeneration",
+			object: &apps_v1.ReplicaSet{
+				ObjectMeta: objectMeta,
+				Spec:       apps_v1.ReplicaSetSpec{Replicas: replicas(2)},
+				Status:     apps_v1.ReplicaSetStatus{ObservedGeneration: 1, ReadyReplicas: 2, AvailableReplicas: 2},
+			},
+			waitFunc:        waitForReplicaSetReplicasFunc,
+			expectError:     true,
+			expectRetryable: true,
+		},
+		{
+			description: "job complete",
+			object:      job(batch_v1.JobCondition{Type: batch_v1.JobComplete, Status: core_v1.ConditionTrue}),
+			waitFunc:    waitForJobCompleteFunc,
+		},
+		{
+			description:     "job running",
+			object:          job(batch_v1.JobCondition{Type: batch_v1.JobFailed, Status: core_v1.ConditionFalse}),
+			waitFunc:        waitForJobCompleteFunc,
+			expectError:     true,
+			expectRetryable: true,
+		},
+		{
+			description: "job failed",
+			object:      job(batch_v1.JobCondition{Type: batch_v1.JobFailed, Status: core_v1.ConditionTrue, Reason: "BackoffLimitExceeded"}),
+			waitFunc:    waitForJobCompleteFunc,
+			expectError: true,
+		},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(tcase.description, func(t *testing.T) {
+			provider := &KubeProvider{restConfig: &restclient.Config{}, mainClientset: kubernetesfake.NewSimpleClientset(tcase.object)}
+
+			retryErr := tcase.waitFunc(context.Background(), provider, "default", "test")()
+			if !tcase.expectError {
+				assert.Nil(t, retryErr)
+				return
+			}
+
+			if assert.NotNil(t, retryErr) {
+				assert.Error(t, retryErr.Err)
+				assert.Equal(t, tcase.expectRetryable, retryErr.Retryable)
+			}
+		})
+	}
+}
+
+func TestAccKubectlWaitFor_namespace(t *testing.T) {
+	config := `
+resource "kubectl_manifest" "test" {
+	yaml_body = <<YAML
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: wait-for-namespace
+YAML
+
+	wait_for {
+		field {
+			key   = "status.phase"
+			value = "Active"
+		}
+		field {
+			key        = "metadata.name"
+			value      = "^wait-for-"
+			value_type = "regex"
+		}
+	}
+}
+`
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckkubectlDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("kubectl_manifest.test", "wait_for.0.field.0.value_type", "eq"),
+				),
+			},
+		},
+	})
+}
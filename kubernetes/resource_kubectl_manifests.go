@@ -0,0 +1,534 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/gavinbunney/terraform-provider-kubectl/yaml"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/id"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	meta_v1_unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func resourceKubectlManifests() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+			return retryApply("creating manifests", func() error {
+				return resourceKubectlManifestsApply(ctx, d, meta)
+			})
+		},
+		ReadContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+			if meta.(*KubeProvider).ConfigUnknown() {
+				log.Printf("[WARN] %s provider configuration is unknown until apply, keeping the prior state", d.Id())
+				return nil
+			}
+
+			if err := resourceKubectlManifestsRead(ctx, d, meta); err != nil {
+				return diag.FromErr(err)
+			}
+
+			return nil
+		},
+		UpdateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+			return retryApply("updating manifests", func() error {
+				return resourceKubectlManifestsApply(ctx, d, meta)
+			})
+		},
+		DeleteContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+			if err := resourceKubectlManifestsDelete(ctx, d, meta); err != nil {
+				return diag.FromErr(err)
+			}
+
+			return nil
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+		},
+		CustomizeDiff: func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+			customizeDiffFieldManager(d, meta.(*KubeProvider))
+
+			if !d.NewValueKnown("yaml_body") {
+				log.Printf("[TRACE] yaml_body value interpolated, skipping customized diff")
+				_ = d.SetNewComputed("objects")
+				return nil
+			}
+
+			// validate the documents can all be parsed
+			if _, err := parseManifestsFromYAML(d.Get("yaml_body").(string), d.Get("override_namespace").(string)); err != nil {
+				return err
+			}
+
+			if d.HasChange("yaml_body") || d.HasChange("override_namespace") || d.HasChange("ignore_fields") {
+				_ = d.SetNewComputed("objects")
+				return nil
+			}
+
+			// the cluster can't be read until apply, so the live state of the objects is unknown
+			if d.Id() != "" && meta.(*KubeProvider).ConfigUnknown() {
+				_ = d.SetNewComputed("objects")
+				return nil
+			}
+
+			// check if any of the tracked objects have been recreated or have drifted
+			for _, object := range expandManifestsObjects(d.Get("objects").([]interface{})) {
+				if object.UID != object.LiveUID || object.Fingerprint != object.LiveFingerprint {
+					log.Printf("[TRACE] DETECTED drift in %s", object.ID)
+					_ = d.SetNewComputed("objects")
+					return nil
+				}
+			}
+
+			return nil
+		},
+		Schema: map[string]*schema.Schema{
+			"yaml_body": {
+				Type:        schema.TypeString,
+				Description: "Multi-document yaml to apply to kubernetes.",
+				Required:    true,
+				Sensitive:   true,
+			},
+			"override_namespace": {
+				Type:        schema.TypeString,
+				Description: "Override the namespace to apply the kubernetes resources to",
+				Optional:    true,
+			},
+			"server_side_apply": {
+				Type:        schema.TypeBool,
+				Description: "Default to client-side-apply. Setting to true will use server-side apply.",
+				Optional:    true,
+				Default:     false,
+			},
+			"force_conflicts": {
+				Type:        schema.TypeBool,
+				Description: "Default false.",
+				Optional:    true,
+				Default:     false,
+			},
+			"field_manager": fieldManagerSchema,
+			"apply_only": {
+				Type:        schema.TypeBool,
+				Description: "Apply only. In other words, it does not delete or prune resources in any case.",
+				Optional:    true,
+				Default:     false,
+			},
+			"ignore_fields": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString, ValidateFunc: validateFieldSelector},
+				Description: "List of yaml keys to ignore changes to in all documents.",
+				Optional:    true,
+			},
+			"wait": {
+				Type:        schema.TypeBool,
+				Description: "Default to false (not waiting). Set this flag to wait or not for any deleted resources to be gone. This waits for finalizers.",
+				Optional:    true,
+			},
+			"wait_for_rollout": {
+				Type:        schema.TypeBool,
+				Description: "Default to true (waiting). Set this flag to wait or not for Deployments, StatefulSets, DaemonSets, ReplicaSets, Jobs and APIService to complete rollout",
+				Optional:    true,
+				Default:     true,
+			},
+			"validate_schema": {
+				Type:        schema.TypeBool,
+				Description: "Default to true (validate). Set this flag to not validate the yaml schema before appying.",
+				Optional:    true,
+				Default:     true,
+			},
+			"objects": {
+				Type:        schema.TypeList,
+				Description: "The kubernetes objects managed from the yaml_body, in the order they were applied.",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"api_version": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"kind": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"namespace": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"uid": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"live_uid": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"yaml_incluster": {
+							Type:      schema.TypeString,
+							Computed:  true,
+							Sensitive: true,
+						},
+						"live_manifest_incluster": {
+							Type:      schema.TypeString,
+							Computed:  true,
+							Sensitive: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// manifestsObject is a kubernetes object tracked by the kubectl_manifests resource
+type manifestsObject struct {
+	ID              string
+	APIVersion      string
+	Kind            string
+	Name            string
+	Namespace       string
+	UID             string
+	LiveUID         string
+	Fingerprint     string
+	LiveFingerprint string
+}
+
+// AsManifest builds a minimal manifest which can be used to find the object in kubernetes
+func (o *manifestsObject) AsManifest() *yaml.Manifest {
+	raw := &meta_v1_unstruct.Unstructured{Object: map[string]interface{}{}}
+	raw.SetAPIVersion(o.APIVersion)
+	raw.SetKind(o.Kind)
+	raw.SetName(o.Name)
+	if o.Namespace != "" {
+		raw.SetNamespace(o.Namespace)
+	}
+	return yaml.NewFromUnstructured(raw)
+}
+
+func expandManifestsObjects(raw []interface{}) []*manifestsObject {
+	objects := make([]*manifestsObject, 0, len(raw))
+	for _, r := range raw {
+		if r == nil {
+			continue
+		}
+		o := r.(map[string]interface{})
+		objects = append(objects, &manifestsObject{
+			ID:              o["id"].(string),
+			APIVersion:      o["api_version"].(string),
+			Kind:            o["kind"].(string),
+			Name:            o["name"].(string),
+			Namespace:       o["namespace"].(string),
+			UID:             o["uid"].(string),
+			LiveUID:         o["live_uid"].(string),
+			Fingerprint:     o["yaml_incluster"].(string),
+			LiveFingerprint: o["live_manifest_incluster"].(string),
+		})
+	}
+	return objects
+}
+
+func flattenManifestsObjects(objects []*manifestsObject) []interface{} {
+	raw := make([]interface{}, 0, len(objects))
+	for _, o := range objects {
+		raw = append(raw, map[string]interface{}{
+			"id":                      o.ID,
+			"api_version":             o.APIVersion,
+			"kind":                    o.Kind,
+			"name":                    o.Name,
+			"namespace":               o.Namespace,
+			"uid":                     o.UID,
+			"live_uid":                o.LiveUID,
+			"yaml_incluster":          o.Fingerprint,
+			"live_manifest_incluster": o.LiveFingerprint,
+		})
+	}
+	return raw
+}
+
+// parseManifestsFromYAML splits the multi-document yaml and parses each document into a manifest
+func parseManifestsFromYAML(yamlBody string, overrideNamespace string) ([]*yaml.Manifest, error) {
+	documents, err := yaml.SplitMultiDocumentYAML(yamlBody)
+	if err != nil {
+		return nil, err
+	}
+
+	manifests := make([]*yaml.Manifest, 0, len(documents))
+	for _, doc := range documents {
+		manifest, err := yaml.ParseYAML(doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse yaml as a kubernetes yaml manifest: %v", err)
+		}
+
+		if overrideNamespace != "" {
+			manifest.SetNamespace(overrideNamespace)
+		}
+
+		manifests = append(manifests, manifest)
+	}
+
+	return manifests, nil
+}
+
+// manifestApplyOrderFirst lists the kinds which other resources are likely to depend on,
+// in the order they should be applied. Any kind not listed is applied afterwards.
+var manifestApplyOrderFirst = []string{
+	"Namespace",
+	"CustomResourceDefinition",
+	"PriorityClass",
+	"ResourceQuota",
+	"LimitRange",
+	"PodSecurityPolicy",
+	"PodDisruptionBudget",
+	"ServiceAccount",
+	"Secret",
+	"SecretList",
+	"ConfigMap",
+	"StorageClass",
+	"PersistentVolume",
+	"PersistentVolumeClaim",
+	"ClusterRole",
+	"ClusterRoleList",
+	"ClusterRoleBinding",
+	"ClusterRoleBindingList",
+	"Role",
+	"RoleList",
+	"RoleBinding",
+	"RoleBindingList",
+	"Service",
+}
+
+// manifestApplyOrderLast lists the kinds which should be applied after all other resources, as they
+// may intercept requests for the resources applied before them
+var manifestApplyOrderLast = []string{
+	"APIService",
+	"MutatingWebhookConfiguration",
+	"ValidatingWebhookConfiguration",
+	"ValidatingAdmissionPolicy",
+	"ValidatingAdmissionPolicyBinding",
+}
+
+func manifestApplyPriority(kind string) int {
+	for i, k := range manifestApplyOrderFirst {
+		if k == kind {
+			return i
+		}
+	}
+	for i, k := range manifestApplyOrderLast {
+		if k == kind {
+			return len(manifestApplyOrderFirst) + 1 + i
+		}
+	}
+	return len(manifestApplyOrderFirst)
+}
+
+// sortManifestsForApply orders the manifests so that dependencies such as Namespaces and CRDs are
+// applied first, and webhooks last. The document order is kept for manifests of the same priority.
+func sortManifestsForApply(manifests []*yaml.Manifest) {
+	sort.SliceStable(manifests, func(i, j int) bool {
+		return manifestApplyPriority(manifests[i].GetKind()) < manifestApplyPriority(manifests[j].GetKind())
+	})
+}
+
+func resourceKubectlManifestsApply(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	provider := meta.(*KubeProvider)
+
+	manifests, err := parseManifestsFromYAML(d.Get("yaml_body").(string), d.Get("override_namespace").(string))
+	if err != nil {
+		return fmt.Errorf("failed to parse kubernetes resources: %+v", err)
+	}
+
+	sortManifestsForApply(manifests)
+
+	var ignoreFields []string
+	if ignoreFieldsRaw, ok := d.GetOk("ignore_fields"); ok {
+		ignoreFields = expandStringList(ignoreFieldsRaw.([]interface{}))
+	}
+
+	previousRaw, _ := d.GetChange("objects")
+	previous := expandManifestsObjects(previousRaw.([]interface{}))
+
+	if d.Id() == "" {
+		d.SetId(id.UniqueId())
+	}
+
+	applied := make([]*manifestsObject, 0, len(manifests))
+	appliedIds := map[string]bool{}
+
+	// on failure, keep tracking any objects from the previous apply so they are not orphaned
+	saveObjects := func() {
+		objects := append([]*manifestsObject(nil), applied...)
+		for _, o := range previous {
+			if !appliedIds[o.ID] {
+				objects = append(objects, o)
+			}
+		}
+		_ = d.Set("objects", flattenManifestsObjects(objects))
+	}
+
+	timeout := d.Timeout(schema.TimeoutCreate)
+	for _, manifest := range manifests {
+		response, _, err := applyManifest(ctx, d, provider, manifest)
+		if err != nil {
+			saveObjects()
+			return err
+		}
+
+		fields, _ := getLiveManifestFields_WithIgnoredFields(ignoreFields, manifest, response)
+		fingerprint := getFingerprint(fields)
+		object := &manifestsObject{
+			ID:              getSelfLink(provider, response),
+			APIVersion:      response.GetAPIVersion(),
+			Kind:            response.GetKind(),
+			Name:            response.GetName(),
+			Namespace:       response.GetNamespace(),
+			UID:             response.GetUID(),
+			LiveUID:         response.GetUID(),
+			Fingerprint:     fingerprint,
+			LiveFingerprint: fingerprint,
+		}
+
+		if appliedIds[object.ID] {
+			saveObjects()
+			return fmt.Errorf("duplicate manifest found with id: %v", object.ID)
+		}
+
+		applied = append(applied, object)
+		appliedIds[object.ID] = true
+
+		if d.Get("wait_for_rollout").(bool) {
+			if check, ok := getRolloutReadinessCheck(manifest); ok {
+				log.Printf("[INFO] %v waiting for %s rollout for %vmin", manifest, check.description, timeout.Minutes())
+				err = resource.RetryContext(ctx, timeout, check.retryFunc(ctx, provider, manifest))
+				if err != nil {
+					saveObjects()
+					return err
+				}
+			}
+		}
+	}
+
+	// prune any objects which are no longer in the yaml_body, in reverse order to how they were applied
+	if !d.Get("apply_only").(bool) {
+		for i := len(previous) - 1; i >= 0; i-- {
+			o := previous[i]
+			if appliedIds[o.ID] {
+				continue
+			}
+
+			log.Printf("[INFO] %v pruning object removed from yaml_body", o.ID)
+			if err := deleteManifest(ctx, provider, o.AsManifest(), d.Get("wait").(bool)); err != nil {
+				previous = previous[:i+1]
+				saveObjects()
+				return err
+			}
+		}
+	}
+
+	_ = d.Set("objects", flattenManifestsObjects(applied))
+	return nil
+}
+
+func resourceKubectlManifestsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	provider := meta.(*KubeProvider)
+
+	manifests, err := parseManifestsFromYAML(d.Get("yaml_body").(string), d.Get("override_namespace").(string))
+	if err != nil {
+		return fmt.Errorf("failed to parse kubernetes resources: %+v", err)
+	}
+
+	var ignoreFields []string
+	if ignoreFieldsRaw, ok := d.GetOk("ignore_fields"); ok {
+		ignoreFields = expandStringList(ignoreFieldsRaw.([]interface{}))
+	}
+
+	objects := expandManifestsObjects(d.Get("objects").([]interface{}))
+	for _, object := range objects {
+		trackedManifest := object.AsManifest()
+		restClient := getRestClientFromUnstructured(trackedManifest, provider)
+		if restClient.Status == RestClientInvalidTypeError {
+			log.Printf("[WARN] kubernetes resource (%s) has an invalid type, marking as gone", object.ID)
+			object.LiveUID = ""
+			continue
+		}
+
+		if restClient.Error != nil {
+			return fmt.Errorf("failed to create kubernetes rest client for read of resource: %+v", restClient.Error)
+		}
+
+		live, err := restClient.ResourceInterface.Get(ctx, object.Name, meta_v1.GetOptions{})
+		if errors.IsGone(err) || errors.IsNotFound(err) {
+			log.Printf("[WARN] kubernetes resource (%s) not found, marking as gone", object.ID)
+			object.LiveUID = ""
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("%v failed to get resource from kubernetes: %+v", trackedManifest, err)
+		}
+
+		liveManifest := yaml.NewFromUnstructured(live)
+		object.LiveUID = liveManifest.GetUID()
+
+		// find the user provided manifest for this object, to fingerprint the same fields as applied
+		for _, manifest := range manifests {
+			if manifest.GetAPIVersion() != object.APIVersion || manifest.GetKind() != object.Kind || manifest.GetName() != object.Name {
+				continue
+			}
+			if manifest.HasNamespace() && manifest.GetNamespace() != object.Namespace {
+				continue
+			}
+
+			// namespaced resources without a namespace are applied to the default namespace
+			if !manifest.HasNamespace() && object.Namespace != "" {
+				manifest.SetNamespace(object.Namespace)
+			}
+
+			fields, _ := getLiveManifestFields_WithIgnoredFields(ignoreFields, manifest, liveManifest)
+			object.LiveFingerprint = getFingerprint(fields)
+			break
+		}
+	}
+
+	_ = d.Set("objects", flattenManifestsObjects(objects))
+	return nil
+}
+
+func resourceKubectlManifestsDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	if d.Get("apply_only").(bool) {
+		return nil
+	}
+
+	provider := meta.(*KubeProvider)
+	objects := expandManifestsObjects(d.Get("objects").([]interface{}))
+
+	// delete in the reverse order to how the objects were applied, so dependencies are removed last
+	for i := len(objects) - 1; i >= 0; i-- {
+		manifest := objects[i].AsManifest()
+		restClient := getRestClientFromUnstructured(manifest, provider)
+		if restClient.Status == RestClientInvalidTypeError {
+			log.Printf("[WARN] kubernetes resource (%s) has an invalid type, skipping delete", objects[i].ID)
+			continue
+		}
+
+		if err := deleteManifest(ctx, provider, manifest, d.Get("wait").(bool)); err != nil {
+			_ = d.Set("objects", flattenManifestsObjects(objects[:i+1]))
+			return err
+		}
+	}
+
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,156 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortManifestsForApply(t *testing.T) {
+	manifests, err := parseManifestsFromYAML(`
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: webhook
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: app
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: app
+`, "")
+	assert.NoError(t, err)
+
+	sortManifestsForApply(manifests)
+
+	var kinds []string
+	for _, m := range manifests {
+		kinds = append(kinds, m.GetKind())
+	}
+
+	assert.Equal(t, []string{
+		"Namespace",
+		"CustomResourceDefinition",
+		"ServiceAccount",
+		"Deployment",
+		"Widget",
+		"ValidatingWebhookConfiguration",
+	}, kinds)
+}
+
+func TestParseManifestsFromYAML_overrideNamespace(t *testing.T) {
+	manifests, err := parseManifestsFromYAML(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: first
+  namespace: default
+---
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: second
+`, "overridden")
+	assert.NoError(t, err)
+	assert.Len(t, manifests, 2)
+	for _, m := range manifests {
+		assert.Equal(t, "overridden", m.GetNamespace())
+	}
+}
+
+func TestAccKubectlManifests_prune(t *testing.T) {
+	configBoth := `
+resource "kubectl_manifests" "test" {
+	yaml_body = <<YAML
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: manifests-first
+  namespace: manifests-prune
+data:
+  key: value
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: manifests-second
+  namespace: manifests-prune
+data:
+  key: value
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: manifests-prune
+YAML
+}
+`
+
+	configFirst := `
+resource "kubectl_manifests" "test" {
+	yaml_body = <<YAML
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: manifests-prune
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: manifests-first
+  namespace: manifests-prune
+data:
+  key: value
+YAML
+}
+`
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: configBoth,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("kubectl_manifests.test", "objects.#", "3"),
+					resource.TestCheckResourceAttr("kubectl_manifests.test", "objects.0.kind", "Namespace"),
+					resource.TestCheckResourceAttr("kubectl_manifests.test", "objects.1.name", "manifests-first"),
+					resource.TestCheckResourceAttr("kubectl_manifests.test", "objects.2.name", "manifests-second"),
+				),
+			},
+			{
+				Config: configFirst,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("kubectl_manifests.test", "objects.#", "2"),
+					resource.TestCheckResourceAttr("kubectl_manifests.test", "objects.1.name", "manifests-first"),
+				),
+			},
+			{
+				Config:             configFirst,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: false,
+			},
+		},
+	})
+}
@@ -2,6 +2,8 @@ package kubernetes
 
 import (
 	"context"
+	"log"
+
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
@@ -9,7 +11,14 @@ import (
 func resourceKubectlServerVersion() *schema.Resource {
 	return &schema.Resource{
 		CreateContext: dataSourceKubectlServerVersionRead,
-		ReadContext:   dataSourceKubectlServerVersionRead,
+		ReadContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+			if meta.(*KubeProvider).ConfigUnknown() {
+				log.Printf("[WARN] %s provider configuration is unknown until apply, keeping the prior state", d.Id())
+				return nil
+			}
+
+			return dataSourceKubectlServerVersionRead(ctx, d, meta)
+		},
 		DeleteContext: resourceKubectlServerVersionDelete,
 		Schema: map[string]*schema.Schema{
 			"triggers": {
@@ -1,6 +1,9 @@
 provider "kubectl" {}
 
 resource "kubectl_manifest" "test" {
+    # the service account doesn't exist so the pods are never created, and the rollout is not waited on
+    wait_for_rollout = false
+
     yaml_body = <<YAML
 apiVersion: apps/v1
 kind: DaemonSet
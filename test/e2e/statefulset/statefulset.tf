@@ -0,0 +1,30 @@
+provider "kubectl" {}
+
+resource "kubectl_manifest" "test" {
+    yaml_body = <<YAML
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: statefulset-name-here
+  labels:
+    app: statefulset-name-here
+spec:
+  replicas: 2
+  serviceName: statefulset-name-here
+  selector:
+    matchLabels:
+      app: statefulset-name-here
+  updateStrategy:
+    type: RollingUpdate
+    rollingUpdate:
+      partition: 1
+  template:
+    metadata:
+      labels:
+        app: statefulset-name-here
+    spec:
+      containers:
+      - name: main
+        image: registry.k8s.io/pause:3.5
+    YAML
+}
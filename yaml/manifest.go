@@ -2,6 +2,7 @@ package yaml
 
 import (
 	"fmt"
+	"k8s.io/apimachinery/pkg/api/meta"
 	meta_v1_unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	yamlWriter "sigs.k8s.io/yaml"
 	"strings"
@@ -54,6 +55,24 @@ func (m *Manifest) GetSelfLink() string {
 	return buildSelfLink(m.GetAPIVersion(), m.GetNamespace(), m.GetKind(), m.GetName())
 }
 
+// GetResourceSelfLink returns the self link of the manifest, using the plural resource name of the kind from the rest mapper.
+// Falls back to the legacy resource name of GetSelfLink when the kind can't be mapped, such as CRDs not yet installed.
+func (m *Manifest) GetResourceSelfLink(mapper meta.RESTMapper) string {
+	selfLink := m.Raw.GetSelfLink()
+	if len(selfLink) > 0 {
+		return selfLink
+	}
+
+	if mapper != nil {
+		gvk := m.Raw.GroupVersionKind()
+		if mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version); err == nil {
+			return buildResourceSelfLink(m.GetAPIVersion(), m.GetNamespace(), mapping.Resource.Resource, m.GetName())
+		}
+	}
+
+	return m.GetSelfLink()
+}
+
 // buildSelfLink creates a selfLink of the form:
 //
 //	"/apis/<apiVersion>/namespaces/<namespace>/<kind>s/<name>"
@@ -61,6 +80,18 @@ func (m *Manifest) GetSelfLink() string {
 // The selfLink attribute is not available in Kubernetes 1.20+ so we need
 // to generate a consistent, unique ID for our Terraform resources.
 func buildSelfLink(apiVersion string, namespace string, kind string, name string) string {
+	var resource string
+	if len(kind) != 0 {
+		resource = LegacyResourceName(kind)
+	}
+
+	return buildResourceSelfLink(apiVersion, namespace, resource, name)
+}
+
+// buildResourceSelfLink creates a selfLink of the form:
+//
+//	"/apis/<apiVersion>/namespaces/<namespace>/<resource>/<name>"
+func buildResourceSelfLink(apiVersion string, namespace string, resource string, name string) string {
 	var linkBuilder strings.Builder
 
 	// for any v1 api served objects, they used to be served from /api
@@ -79,14 +110,8 @@ func buildSelfLink(apiVersion string, namespace string, kind string, name string
 		_, _ = fmt.Fprintf(&linkBuilder, "/namespaces/%s", namespace)
 	}
 
-	if len(kind) != 0 {
-		var suffix string
-		if strings.HasSuffix(kind, "s") {
-			suffix = "es"
-		} else {
-			suffix = "s"
-		}
-		_, _ = fmt.Fprintf(&linkBuilder, "/%s%s", strings.ToLower(kind), suffix)
+	if len(resource) != 0 {
+		_, _ = fmt.Fprintf(&linkBuilder, "/%s", resource)
 	}
 
 	if len(name) != 0 {
@@ -95,6 +120,15 @@ func buildSelfLink(apiVersion string, namespace string, kind string, name string
 	return linkBuilder.String()
 }
 
+// LegacyResourceName returns the resource name used for the kind within self links built by buildSelfLink,
+// which pluralizes the lower cased kind by appending "s", or "es" for kinds already ending in "s"
+func LegacyResourceName(kind string) string {
+	if strings.HasSuffix(kind, "s") {
+		return strings.ToLower(kind) + "es"
+	}
+	return strings.ToLower(kind) + "s"
+}
+
 func (m *Manifest) String() string {
 	if m.HasNamespace() {
 		return fmt.Sprintf("%s/%s", m.Raw.GetNamespace(), m.Raw.GetName())
@@ -2,6 +2,9 @@ package yaml
 
 import (
 	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	meta_v1_unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"testing"
 )
 
@@ -18,3 +21,31 @@ func TestBuildSelfLink(t *testing.T) {
 	link = buildSelfLink("apps/v1", "ns", "Deployment", "name")
 	assert.Equal(t, link, "/apis/apps/v1/namespaces/ns/deployments/name")
 }
+
+func TestGetResourceSelfLink(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.AddSpecific(schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "NetworkPolicy"},
+		schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"},
+		schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicy"},
+		meta.RESTScopeNamespace)
+	mapper.AddSpecific(schema.GroupVersionKind{Version: "v1", Kind: "Endpoints"},
+		schema.GroupVersionResource{Version: "v1", Resource: "endpoints"},
+		schema.GroupVersionResource{Version: "v1", Resource: "endpoints"},
+		meta.RESTScopeNamespace)
+
+	manifest := func(apiVersion string, kind string) *Manifest {
+		raw := &meta_v1_unstruct.Unstructured{}
+		raw.SetAPIVersion(apiVersion)
+		raw.SetKind(kind)
+		raw.SetNamespace("ns")
+		raw.SetName("name")
+		return NewFromUnstructured(raw)
+	}
+
+	assert.Equal(t, "/apis/networking.k8s.io/v1/namespaces/ns/networkpolicies/name", manifest("networking.k8s.io/v1", "NetworkPolicy").GetResourceSelfLink(mapper))
+	assert.Equal(t, "/api/v1/namespaces/ns/endpoints/name", manifest("v1", "Endpoints").GetResourceSelfLink(mapper))
+
+	// unmapped kinds fall back to the legacy resource name
+	assert.Equal(t, "/apis/example.com/v1/namespaces/ns/widgets/name", manifest("example.com/v1", "Widget").GetResourceSelfLink(mapper))
+	assert.Equal(t, "/apis/networking.k8s.io/v1/namespaces/ns/networkpolicys/name", manifest("networking.k8s.io/v1", "NetworkPolicy").GetResourceSelfLink(nil))
+}